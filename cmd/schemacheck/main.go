@@ -0,0 +1,92 @@
+// Command schemacheck compares resources, read from JSON files, with the tables of a database
+// and prints the drifts found. It exits with status 1 if there is any drift.
+//
+// Usage:
+//
+//	schemacheck -driver mysql -dsn "user:pwd@tcp(host:3306)/schema" users.json vehicles.json
+//	schemacheck -driver sqlite -dsn gosimplerest.db -json users.json
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	mysqlRepo "github.com/franciscoescher/gosimplerest/repository/mysql"
+	sqliteRepo "github.com/franciscoescher/gosimplerest/repository/sqlite"
+	"github.com/franciscoescher/gosimplerest/resource"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	driver := flag.String("driver", "mysql", "database driver: mysql or sqlite")
+	dsn := flag.String("dsn", os.Getenv("DB_DSN"), "data source name of the database, read from DB_DSN if not set")
+	asJSON := flag.Bool("json", false, "print the report as json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] resource.json...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	resources := make([]*resource.Resource, flag.NArg())
+	for i, file := range flag.Args() {
+		resources[i] = &resource.Resource{}
+		err := resources[i].FromJSON(file)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", file, err))
+		}
+	}
+
+	report, err := check(*driver, *dsn, resources)
+	if err != nil {
+		fail(err)
+	}
+
+	if *asJSON {
+		err = json.NewEncoder(os.Stdout).Encode(report)
+		if err != nil {
+			fail(err)
+		}
+	} else {
+		fmt.Print(report.String())
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// check opens the database and checks the schema of the resources
+func check(driver, dsn string, resources []*resource.Resource) (repository.SchemaReport, error) {
+	var db *sql.DB
+	var err error
+	switch driver {
+	case "mysql":
+		db, err = sql.Open("mysql", dsn)
+	case "sqlite":
+		db, err = sqliteRepo.Open(dsn)
+	default:
+		return repository.SchemaReport{}, fmt.Errorf("unknown driver %s", driver)
+	}
+	if err != nil {
+		return repository.SchemaReport{}, err
+	}
+	defer db.Close()
+
+	if driver == "sqlite" {
+		return sqliteRepo.CheckSchema(db, resources...)
+	}
+	return mysqlRepo.CheckSchema(db, resources...)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
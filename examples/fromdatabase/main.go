@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+
+	"github.com/franciscoescher/gosimplerest"
+	mysqlRepo "github.com/franciscoescher/gosimplerest/repository/mysql"
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	logger := logrus.New()
+
+	logger.Info("starting application")
+
+	db := getDB()
+	defer db.Close()
+
+	// load a resource for each table of the database
+	resources, err := mysqlRepo.ResourcesFromDatabase(context.Background(), db)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	// create routes for rest api
+	r := gin.Default()
+	params := gosimplerest.AddHandlersBaseParams{Logger: logger, Resources: resources, Respository: mysqlRepo.NewRepository(db)}
+	gosimplerest.AddGinHandlers(r, params)
+
+	logrus.Fatal(r.Run(":3333"))
+}
+
+func getDB() *sql.DB {
+	c := mysql.Config{
+		User:                 os.Getenv("DB_USER"),
+		Passwd:               os.Getenv("DB_PASSWORD"),
+		Net:                  "tcp",
+		Addr:                 os.Getenv("DB_HOSTNAME") + ":" + os.Getenv("DB_PORT"),
+		DBName:               os.Getenv("DB_SCHEMA"),
+		ParseTime:            true,
+		AllowNativePasswords: true,
+	}
+
+	db, err := sql.Open("mysql", c.FormatDSN())
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
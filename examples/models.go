@@ -1,6 +1,7 @@
 package examples
 
-// Example implementation for the following mysql data structures:
+// Example implementation for the following mysql data structures,
+// which can also be created from the resources with mysql.AutoMigrate:
 /*
 DROP TABLE IF EXISTS `rent_events`;
 DROP TABLE IF EXISTS `vehicles`;
@@ -67,9 +68,9 @@ var UserResource = resource.Resource{
 		"last_name":   {},
 		"phone":       {},
 		"credit_card": {Unsearchable: true},
-		"created_at":  {},
-		"deleted_at":  {},
-		"updated_at":  {},
+		"created_at":  {Type: resource.TypeTime},
+		"deleted_at":  {Type: resource.TypeTime},
+		"updated_at":  {Type: resource.TypeTime},
 	},
 	SoftDeleteField: null.NewString("deleted_at", true),
 	CreatedAtField:  null.NewString("created_at", true),
@@ -83,14 +84,14 @@ var RentEventResource = resource.Resource{
 		"uuid":          {Validator: "uuid4"},
 		"user_id":       {Validator: "uuid4"},
 		"vehicle_id":    {Validator: "uuid4"},
-		"starting_time": {},
-		"hours":         {},
-		"checkin_time":  {},
-		"dropoff_time":  {},
-		"cancel_time":   {},
-		"created_at":    {},
-		"deleted_at":    {},
-		"updated_at":    {},
+		"starting_time": {Type: resource.TypeTime},
+		"hours":         {Type: resource.TypeInt},
+		"checkin_time":  {Type: resource.TypeTime},
+		"dropoff_time":  {Type: resource.TypeTime},
+		"cancel_time":   {Type: resource.TypeTime},
+		"created_at":    {Type: resource.TypeTime},
+		"deleted_at":    {Type: resource.TypeTime},
+		"updated_at":    {Type: resource.TypeTime},
 	},
 	SoftDeleteField: null.NewString("deleted_at", true),
 	CreatedAtField:  null.NewString("created_at", true),
@@ -104,13 +105,13 @@ var VehicleResource = resource.Resource{
 		"uuid":           {Validator: "uuid4"},
 		"license_plate":  {},
 		"state":          {},
-		"archived":       {},
-		"year":           {},
-		"price_per_hour": {},
-		"lot":            {},
-		"created_at":     {},
-		"deleted_at":     {},
-		"updated_at":     {},
+		"archived":       {Type: resource.TypeBool},
+		"year":           {Type: resource.TypeInt},
+		"price_per_hour": {Type: resource.TypeFloat},
+		"lot":            {Type: resource.TypeInt},
+		"created_at":     {Type: resource.TypeTime},
+		"deleted_at":     {Type: resource.TypeTime},
+		"updated_at":     {Type: resource.TypeTime},
 	},
 	SoftDeleteField: null.NewString("deleted_at", true),
 	CreatedAtField:  null.NewString("created_at", true),
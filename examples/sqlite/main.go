@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/franciscoescher/gosimplerest"
+	"github.com/franciscoescher/gosimplerest/examples"
+	sqliteRepo "github.com/franciscoescher/gosimplerest/repository/sqlite"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "print the statements that migrate the database and exit")
+	flag.Parse()
+
+	logger := logrus.New()
+
+	logger.Info("starting application")
+
+	// the database file is read from the DB_FILE environment variable
+	file := os.Getenv("DB_FILE")
+	if file == "" {
+		file = "gosimplerest.db"
+	}
+	db, err := sqliteRepo.Open(file)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer db.Close()
+
+	// creates the tables, columns and indexes missing in the database
+	migrator := sqliteRepo.NewMigrator(db)
+	migrator.DryRun = *dryRun
+	err = migrator.AutoMigrate(context.Background(), &examples.UserResource)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if *dryRun {
+		return
+	}
+
+	// create router
+	r := gin.Default()
+
+	// create routes for rest api
+	resources := []resource.Resource{examples.UserResource}
+	params := gosimplerest.AddHandlersBaseParams{Logger: logger, Resources: resources, Respository: sqliteRepo.NewRepository(db)}
+	gosimplerest.AddGinHandlers(r, params)
+
+	log.Fatal(r.Run(":3333"))
+}
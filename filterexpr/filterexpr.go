@@ -0,0 +1,304 @@
+// Package filterexpr parses the boolean filter expressions of the search route, such as
+//
+//	(first_name like 'a%' or phone is null) and not age < 18
+//
+// into a repository.Expr validated against a resource. Comparisons are field op value,
+// where the operator is one of = != <> > >= < <= or the name of an operator (eq, ne,
+// gt, gte, lt, lte), field in (values), field nin (values), field not in (values),
+// field is [not] null and field like 'pattern'. Values are 'strings', with quotes
+// escaped by doubling them, numbers, true and false. The and, or and not keywords
+// combine the comparisons, with not binding tighter than and, and and tighter than or.
+// Keywords are case insensitive.
+package filterexpr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/franciscoescher/gosimplerest/validator"
+)
+
+const (
+	// MaxLength is the maximum length of an expression, in bytes
+	MaxLength = 2048
+	// MaxDepth is the maximum nesting of the parentheses and nots of an expression
+	MaxDepth = 32
+)
+
+// Error is an error of the syntax or the validation of an expression,
+// with the position in the input where it was found
+type Error struct {
+	// Pos is the byte offset of the error in the input
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter: %s at position %d", e.Msg, e.Pos)
+}
+
+// Parse parses the expression, returning its tree. The fields must be searchable, the
+// operators allowed for them, and the values valid for their types and validation rules.
+func Parse(b *resource.Resource, v validator.Validator, input string) (repository.Expr, error) {
+	if len(input) > MaxLength {
+		return nil, &Error{Pos: MaxLength, Msg: fmt.Sprintf("expression longer than %d bytes", MaxLength)}
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{resource: b, validate: v, tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return e, nil
+}
+
+// comparisons are the comparison operators written as symbols or names
+var comparisons = map[string]resource.Operator{
+	"=":   resource.OpEq,
+	"!=":  resource.OpNe,
+	"<>":  resource.OpNe,
+	">":   resource.OpGt,
+	">=":  resource.OpGte,
+	"<":   resource.OpLt,
+	"<=":  resource.OpLte,
+	"eq":  resource.OpEq,
+	"ne":  resource.OpNe,
+	"gt":  resource.OpGt,
+	"gte": resource.OpGte,
+	"lt":  resource.OpLt,
+	"lte": resource.OpLte,
+}
+
+// parser is a recursive descent parser of the tokens of an expression
+type parser struct {
+	resource *resource.Resource
+	validate validator.Validator
+	tokens   []token
+	pos      int
+	depth    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given keyword
+func (p *parser) accept(keyword string) bool {
+	if p.peek().is(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// enter increases the nesting of the expression, returning an error past MaxDepth
+func (p *parser) enter(t token) error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return p.errorf(t, "expression nested deeper than %d", MaxDepth)
+	}
+	return nil
+}
+
+// or parses: and (or and)*
+func (p *parser) or() (repository.Expr, error) {
+	e, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	or := repository.Or{e}
+	for p.accept("or") {
+		e, err = p.and()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, e)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+// and parses: unary (and unary)*
+func (p *parser) and() (repository.Expr, error) {
+	e, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	and := repository.And{e}
+	for p.accept("and") {
+		e, err = p.unary()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, e)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+// unary parses: not unary | ( or ) | comparison
+func (p *parser) unary() (repository.Expr, error) {
+	t := p.peek()
+	switch {
+	case t.is("not"):
+		p.next()
+		if err := p.enter(t); err != nil {
+			return nil, err
+		}
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		p.depth--
+		return repository.Not{Expr: e}, nil
+	case t.kind == tokenSymbol && t.text == "(":
+		p.next()
+		if err := p.enter(t); err != nil {
+			return nil, err
+		}
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokenSymbol || c.text != ")" {
+			return nil, p.errorf(c, "expected ) instead of %s", c)
+		}
+		p.depth--
+		return e, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses: field (op value | [not] in (values) | nin (values) | is [not] null | like string)
+func (p *parser) comparison() (repository.Expr, error) {
+	t := p.next()
+	if t.kind != tokenIdent || t.keyword() {
+		return nil, p.errorf(t, "expected a field instead of %s", t)
+	}
+	field := t.text
+	if !p.resource.IsSearchable(field) {
+		return nil, p.errorf(t, "%s is not searchable", field)
+	}
+
+	opToken := p.next()
+	f := repository.Filter{Field: field}
+	switch {
+	case opToken.is("is"):
+		f.Op = resource.OpNull
+		null := !p.accept("not")
+		if n := p.next(); !n.is("null") {
+			return nil, p.errorf(n, "expected null instead of %s", n)
+		}
+		f.Values = []any{null}
+	case opToken.is("like"):
+		f.Op = resource.OpLike
+		s := p.next()
+		if s.kind != tokenString {
+			return nil, p.errorf(s, "expected a string pattern instead of %s", s)
+		}
+		f.Values = []any{s.text}
+	case opToken.is("in"), opToken.is("nin"), opToken.is("not"):
+		f.Op = resource.OpIn
+		if opToken.is("nin") {
+			f.Op = resource.OpNin
+		} else if opToken.is("not") {
+			if n := p.next(); !n.is("in") {
+				return nil, p.errorf(n, "expected in instead of %s", n)
+			}
+			f.Op = resource.OpNin
+		}
+		values, err := p.list(field)
+		if err != nil {
+			return nil, err
+		}
+		f.Values = values
+	default:
+		op, ok := comparisons[strings.ToLower(opToken.text)]
+		if !ok || opToken.kind == tokenString || opToken.kind == tokenNumber {
+			return nil, p.errorf(opToken, "expected an operator instead of %s", opToken)
+		}
+		f.Op = op
+		value, err := p.value(field)
+		if err != nil {
+			return nil, err
+		}
+		f.Values = []any{value}
+	}
+	if !p.resource.AllowsOperator(field, f.Op) {
+		return nil, p.errorf(opToken, "operator %s is not allowed for %s", f.Op, field)
+	}
+	return f, nil
+}
+
+// list parses: ( value (, value)* )
+func (p *parser) list(field string) ([]any, error) {
+	if t := p.next(); t.kind != tokenSymbol || t.text != "(" {
+		return nil, p.errorf(t, "expected ( instead of %s", t)
+	}
+	var values []any
+	for {
+		v, err := p.value(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		t := p.next()
+		if t.kind == tokenSymbol && t.text == ")" {
+			return values, nil
+		}
+		if t.kind != tokenSymbol || t.text != "," {
+			return nil, p.errorf(t, "expected , or ) instead of %s", t)
+		}
+	}
+}
+
+// value parses a string, number or boolean, converted to the type of the field and validated
+func (p *parser) value(field string) (any, error) {
+	t := p.next()
+	var raw any
+	switch {
+	case t.kind == tokenString:
+		raw = t.text
+	case t.kind == tokenNumber:
+		raw = json.Number(t.text)
+	case t.is("true"):
+		raw = true
+	case t.is("false"):
+		raw = false
+	default:
+		return nil, p.errorf(t, "expected a value instead of %s", t)
+	}
+	v, err := p.resource.ParseValue(field, raw)
+	if err == nil {
+		err = p.resource.ValidateField(p.validate, field, v)
+	}
+	if err != nil {
+		return nil, p.errorf(t, "%s", err)
+	}
+	return v, nil
+}
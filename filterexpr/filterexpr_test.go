@@ -0,0 +1,109 @@
+package filterexpr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userResource = resource.Resource{
+	Name:       "users",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":         {Type: resource.TypeInt, Operators: []resource.Operator{resource.OpIn, resource.OpNin}},
+		"first_name": {Type: resource.TypeString, Operators: []resource.Operator{resource.OpLike}},
+		"phone":      {Type: resource.TypeString, Operators: []resource.Operator{resource.OpNull}},
+		"age":        {Type: resource.TypeInt, Validator: "min=0", Operators: []resource.Operator{resource.OpLt, resource.OpGte, resource.OpNe}},
+		"balance":    {Type: resource.TypeDecimal, Operators: []resource.Operator{resource.OpGt}},
+		"active":     {Type: resource.TypeBool},
+		"password":   {Type: resource.TypeString, Unsearchable: true},
+	},
+}
+
+func filter(field string, op resource.Operator, values ...any) repository.Filter {
+	return repository.Filter{Field: field, Op: op, Values: values}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  repository.Expr
+	}{
+		{"age >= 18", filter("age", resource.OpGte, int64(18))},
+		{"age GTE 18", filter("age", resource.OpGte, int64(18))},
+		{"first_name = 'O''Brien'", filter("first_name", resource.OpEq, "O'Brien")},
+		{"balance > 10.50", filter("balance", resource.OpGt, json.Number("10.50"))},
+		{"active = true", filter("active", resource.OpEq, true)},
+		{"age <> 3", filter("age", resource.OpNe, int64(3))},
+		{"id in (1, 2)", filter("id", resource.OpIn, int64(1), int64(2))},
+		{"id not in (1)", filter("id", resource.OpNin, int64(1))},
+		{"id NIN (1)", filter("id", resource.OpNin, int64(1))},
+		{"phone is null", filter("phone", resource.OpNull, true)},
+		{"phone is not null", filter("phone", resource.OpNull, false)},
+		{"first_name like 'a%'", filter("first_name", resource.OpLike, "a%")},
+		{
+			"(first_name like 'A%' or phone is null) and not age < 18",
+			repository.And{
+				repository.Or{filter("first_name", resource.OpLike, "A%"), filter("phone", resource.OpNull, true)},
+				repository.Not{Expr: filter("age", resource.OpLt, int64(18))},
+			},
+		},
+		{
+			"id = 1 or id = 2 and age = 3 or not not active = false",
+			repository.Or{
+				filter("id", resource.OpEq, int64(1)),
+				repository.And{filter("id", resource.OpEq, int64(2)), filter("age", resource.OpEq, int64(3))},
+				repository.Not{Expr: repository.Not{Expr: filter("active", resource.OpEq, false)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		e, err := Parse(&userResource, validator.New(), tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, e, tt.input)
+		assert.NoError(t, repository.ValidateExpr(&userResource, e), tt.input)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"", 0},
+		{"age >= 18 and", 13},
+		{"(age >= 18", 10},
+		{"age >= 18)", 9},
+		{"age 18", 4},
+		{"age > 18", 4},
+		{"age >= -1", 7},
+		{"age >= 'old'", 7},
+		{"age >= 1-2", 7},
+		{"password = 'secret'", 0},
+		{"missing = 1", 0},
+		{"and = 1", 0},
+		{"first_name = 'open", 13},
+		{"first_name like 1", 16},
+		{"phone is 1", 9},
+		{"id in ()", 7},
+		{"id in (1 2)", 9},
+		{"age ! 1", 4},
+		{"age = 1; drop table users", 7},
+		{strings.Repeat("(", MaxDepth+1) + "age = 1" + strings.Repeat(")", MaxDepth+1), MaxDepth},
+		{strings.Repeat("not ", MaxDepth+1) + "age = 1", MaxDepth * 4},
+		{"age = 1" + strings.Repeat(" ", MaxLength), MaxLength},
+	}
+	for _, tt := range tests {
+		_, err := Parse(&userResource, validator.New(), tt.input)
+		var exprErr *Error
+		if assert.ErrorAs(t, err, &exprErr, tt.input) {
+			assert.Equal(t, tt.pos, exprErr.Pos, tt.input)
+		}
+	}
+}
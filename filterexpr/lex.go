@@ -0,0 +1,131 @@
+package filterexpr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	// tokenIdent is a field name or a keyword
+	tokenIdent
+	// tokenString is a quoted string, with its quotes removed and unescaped
+	tokenString
+	tokenNumber
+	// tokenSymbol is a parenthesis, a comma or a comparison operator
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// keywords are the words that can not be field names
+var keywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "nin": true,
+	"is": true, "null": true, "like": true, "true": true, "false": true,
+}
+
+// is returns true if the token is the given keyword, ignoring case
+func (t token) is(keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+// keyword returns true if the token is a keyword
+func (t token) keyword() bool {
+	return t.kind == tokenIdent && keywords[strings.ToLower(t.text)]
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return "string '" + strings.ReplaceAll(t.text, "'", "''") + "'"
+	}
+	return t.text
+}
+
+// lex splits the input into tokens, ending with a tokenEOF
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ',' || c == '=':
+			tokens = append(tokens, token{kind: tokenSymbol, text: input[i : i+1], pos: i})
+			i++
+		case c == '!' || c == '<' || c == '>':
+			end := i + 1
+			if end < len(input) && (input[end] == '=' || c == '<' && input[end] == '>') {
+				end++
+			}
+			if input[i:end] == "!" {
+				return nil, &Error{Pos: i, Msg: "unexpected !"}
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: input[i:end], pos: i})
+			i = end
+		case c == '\'':
+			s, end, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, pos: i})
+			i = end
+		case isDigit(c) || c == '-' || c == '.':
+			end := i + 1
+			for end < len(input) && (isDigit(input[end]) || strings.IndexByte(".eE+-", input[end]) >= 0) {
+				end++
+			}
+			if !json.Valid([]byte(input[i:end])) {
+				return nil, &Error{Pos: i, Msg: "invalid number " + input[i:end]}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[i:end], pos: i})
+			i = end
+		case isIdentStart(c):
+			end := i + 1
+			for end < len(input) && (isIdentStart(input[end]) || isDigit(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[i:end], pos: i})
+			i = end
+		default:
+			return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+// lexString reads the quoted string starting at start, where quotes are escaped
+// by doubling them, returning it and the position after its closing quote
+func lexString(input string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		if input[i] != '\'' {
+			sb.WriteByte(input[i])
+			continue
+		}
+		if i+1 < len(input) && input[i+1] == '\'' {
+			sb.WriteByte('\'')
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, &Error{Pos: start, Msg: "unterminated string"}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+)
+
+// groupByParam is the query param with the group fields of the aggregate route
+const groupByParam = "group_by"
+
+// aggregateFuncs are the aggregate functions, each read from the query param of its name
+var aggregateFuncs = []repository.AggregateFunc{repository.AggCount, repository.AggSum, repository.AggAvg, repository.AggMin, repository.AggMax}
+
+// aggregateParams are the query params of the aggregate route that are not fields
+var aggregateParams = []string{includeDeletedParam, filterParam, textParam, groupByParam,
+	string(repository.AggCount), string(repository.AggSum), string(repository.AggAvg), string(repository.AggMin), string(repository.AggMax)}
+
+// AggregateHandler returns a handler for the GET method of the aggregate route, which groups
+// the rows selected as in the search route by the fields of the group_by query param,
+// and returns the count, sum, avg, min and max of the fields of the params of their names.
+// The fields are comma separated, and count also takes *, the count of the rows.
+// The full-text search of the q param is done by the repository, even with a SearchIndex.
+func AggregateHandler(params *GetHandlerFuncParams) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := params.Resource.QueryContext(r.Context())
+		defer cancel()
+
+		aggregator, ok := params.Repository.(repository.Aggregator)
+		if !ok {
+			w.WriteHeader(http.StatusNotImplemented)
+			err := encodeJsonError(w, r, "aggregates are not supported by the repository")
+			if err != nil {
+				params.Logger.Error(err)
+			}
+			return
+		}
+
+		query := repository.AggregateQuery{GroupBy: readList(r, groupByParam)}
+		for _, fn := range aggregateFuncs {
+			for _, field := range readList(r, string(fn)) {
+				query.Aggregates = append(query.Aggregates, repository.Aggregate{Func: fn, Field: field})
+			}
+		}
+		var err error
+		query.IncludeDeleted, err = readIncludeDeleted(r, params)
+		if err == nil {
+			query.Expr, err = readFilterExpr(r, params)
+		}
+		if err == nil {
+			query.Text, err = readText(r, params.Resource)
+		}
+		if err == nil {
+			query.Where, query.Filters, err = readWhere(r, params, aggregateParams)
+		}
+		if err == nil {
+			err = repository.ValidateAggregate(params.Resource, query)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			err = encodeJsonError(w, r, err.Error())
+			if err != nil {
+				params.Logger.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		result, err := aggregator.Aggregate(ctx, params.Resource, query)
+		if err != nil {
+			writeRepositoryError(w, r, params, err)
+			return
+		}
+		err = encodeJson(w, r, result)
+		if err != nil {
+			params.Logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// readList reads a query param as a comma separated list, returning nil if it is not set
+func readList(r *http.Request, param string) []string {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return nil
+	}
+	list := strings.Split(value, ",")
+	for i, item := range list {
+		list[i] = strings.TrimSpace(item)
+	}
+	return list
+}
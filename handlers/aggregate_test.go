@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rentResource = resource.Resource{
+	Name:       "rents",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":         {Type: resource.TypeInt},
+		"vehicle_id": {Type: resource.TypeInt, Aggregatable: true, Operators: []resource.Operator{resource.OpGt}},
+		"hours":      {Type: resource.TypeInt, Aggregatable: true, Operators: []resource.Operator{resource.OpGt}},
+		"plate":      {Type: resource.TypeString},
+	},
+}
+
+func TestAggregateHandler(t *testing.T) {
+	// Prepare the test
+	params := &GetHandlerFuncParams{Resource: &rentResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	for i, rent := range [][2]int64{{1, 2}, {1, 3}, {2, 5}, {3, 1}} {
+		_, err := params.Repository.Insert(context.Background(), &rentResource, map[string]any{
+			"id": int64(i + 1), "vehicle_id": rent[0], "hours": rent[1], "plate": "ABC",
+		})
+		require.NoError(t, err)
+	}
+	aggregate := func(params *GetHandlerFuncParams, query string) (int, []map[string]any) {
+		request, err := http.NewRequest(http.MethodGet, "/rents/_aggregate?"+query, nil)
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		http.HandlerFunc(AggregateHandler(params)).ServeHTTP(response, request)
+		var rows []map[string]any
+		if response.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+		}
+		return response.Code, rows
+	}
+
+	// Make assertions
+	code, rows := aggregate(params, "group_by=vehicle_id&sum=hours&count=*")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []map[string]any{
+		{"vehicle_id": 1.0, "sum_hours": 5.0, "count": 2.0},
+		{"vehicle_id": 2.0, "sum_hours": 5.0, "count": 1.0},
+		{"vehicle_id": 3.0, "sum_hours": 1.0, "count": 1.0},
+	}, rows)
+
+	// the rows are selected by the search filters
+	code, rows = aggregate(params, "vehicle_id[gt]=1&plate=ABC&max=hours,vehicle_id")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []map[string]any{{"max_hours": 5.0, "max_vehicle_id": 3.0}}, rows)
+	code, rows = aggregate(params, "group_by=vehicle_id&count=*&filter="+"hours+>+10")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []map[string]any{}, rows)
+
+	for _, query := range []string{
+		"group_by=vehicle_id",
+		"group_by=plate&count=*",
+		"count=plate",
+		"sum=*",
+		"sum=hours,hours",
+		"count=*&unknown=1",
+		"count=*&vehicle_id=one",
+	} {
+		code, _ = aggregate(params, query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+
+	// repositories that do not aggregate do not implement the route
+	withoutAggregates := *params
+	withoutAggregates.Repository = struct{ repository.RepositoryInterface }{params.Repository}
+	code, _ = aggregate(&withoutAggregates, "count=*")
+	assert.Equal(t, http.StatusNotImplemented, code)
+}
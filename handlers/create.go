@@ -2,78 +2,154 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"encoding/json"
 	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
 )
 
 // CreateHandler returns a handler for the POST method
 func CreateHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		data, err := unmarshalBody(r)
-		if err != nil {
-			params.Logger.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+		create(w, r, params, nil, nil, nil)
+	}
+}
 
-		if !params.Resource.AutoIncrementalPK {
-			pk := params.Resource.GeneratePrimaryKey()
-			data[params.Resource.PrimaryKey] = pk
-		}
-		if params.Resource.CreatedAtField.Valid {
-			data[params.Resource.CreatedAtField.String] = time.Now()
-		}
-		if params.Resource.UpdatedAtField.Valid {
-			data[params.Resource.UpdatedAtField.String] = time.Now()
-		}
-		if params.Resource.SoftDeleteField.Valid {
-			data[params.Resource.SoftDeleteField.String] = nil
-		}
+// checkFunc checks that the row of a nested route can be inserted, such as that its parent
+// exists, in the transaction of the insert
+type checkFunc func(ctx context.Context, tx repository.RepositoryInterface) error
 
-		// perform data validation
-		for key := range data {
-			// validates field exists in the model
-			if !params.Resource.HasField(key) {
-				w.WriteHeader(http.StatusBadRequest)
-				err = encodeJsonError(w, r, key+" not in the model")
-				if err != nil {
-					params.Logger.Error(err)
-					w.WriteHeader(http.StatusInternalServerError)
-				}
-				return
-			}
-		}
-		// validates values
-		errs := params.Resource.ValidateAllFields(params.Validate, data)
-		if len(errs) > 0 {
+// linkFunc relates a row inserted by a nested route to the row of its parent,
+// in the transaction of the insert
+type linkFunc func(ctx context.Context, tx repository.RepositoryInterface, row map[string]any) error
+
+// invalidRowError is returned by a linkFunc when the row it inserts is not valid,
+// and is written to the client as the errors of the body are
+type invalidRowError struct {
+	msg string
+}
+
+func (e invalidRowError) Error() string {
+	return e.msg
+}
+
+// create inserts the row of the body, with the values of scope set over the ones of the body.
+// If not nil, check runs before the insert, and link after it, with the row and its primary key.
+func create(w http.ResponseWriter, r *http.Request, params *GetHandlerFuncParams, scope map[string]any, check checkFunc, link linkFunc) {
+	ctx, cancel := params.Resource.QueryContext(r.Context())
+	defer cancel()
+
+	data, err := unmarshalBody(r)
+	if err != nil {
+		params.Logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	for field, v := range scope {
+		data[field] = v
+	}
+	prepareInsert(params.Resource, data)
+
+	// perform data validation
+	for key := range data {
+		// validates field exists in the model
+		if !params.Resource.HasField(key) {
 			w.WriteHeader(http.StatusBadRequest)
-			err = encodeJsonError(w, r, fmt.Sprintf("%s", errs))
+			err = encodeJsonError(w, r, key+" not in the model")
 			if err != nil {
 				params.Logger.Error(err)
 				w.WriteHeader(http.StatusInternalServerError)
 			}
 			return
 		}
-
-		id, err := params.Repository.Insert(params.Resource, data)
+	}
+	// converts values to the types of the fields
+	err = params.Resource.ParseRow(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		err = encodeJsonError(w, r, err.Error())
 		if err != nil {
 			params.Logger.Error(err)
 			w.WriteHeader(http.StatusInternalServerError)
-			return
+		}
+		return
+	}
+	// validates values
+	errs := params.Resource.ValidateAllFields(params.Validate, data)
+	if len(errs) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		err = encodeJsonError(w, r, fmt.Sprintf("%s", errs))
+		if err != nil {
+			params.Logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var id int64
+	err = repository.WithTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+		if check != nil {
+			err := check(ctx, tx)
+			if err != nil {
+				return err
+			}
+		}
+		id, err = tx.Insert(ctx, params.Resource, data)
+		if err != nil || link == nil {
+			return err
 		}
 		if params.Resource.AutoIncrementalPK {
 			data[params.Resource.PrimaryKey] = id
 		}
-
-		err = encodeJson(w, r, map[string]any{params.Resource.PrimaryKey: data[params.Resource.PrimaryKey]})
+		return link(ctx, tx, data)
+	})
+	var invalid invalidRowError
+	if errors.As(err, &invalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		err = encodeJsonError(w, r, invalid.msg)
 		if err != nil {
 			params.Logger.Error(err)
 			w.WriteHeader(http.StatusInternalServerError)
-			return
 		}
+		return
+	}
+	if err != nil {
+		writeRepositoryError(w, r, params, err)
+		return
+	}
+	if params.Resource.AutoIncrementalPK {
+		data[params.Resource.PrimaryKey] = id
+	}
+	syncIndex(ctx, params, data[params.Resource.PrimaryKey])
+
+	err = encodeJson(w, r, map[string]any{params.Resource.PrimaryKey: data[params.Resource.PrimaryKey]})
+	if err != nil {
+		params.Logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// prepareInsert sets the values of a new row that are not given by the client: the primary key,
+// if it is not auto incremental, the creation and update timestamps and the soft delete field
+func prepareInsert(b *resource.Resource, data map[string]any) {
+	if !b.AutoIncrementalPK {
+		data[b.PrimaryKey] = b.GeneratePrimaryKey()
+	}
+	if b.CreatedAtField.Valid {
+		data[b.CreatedAtField.String] = time.Now()
+	}
+	if b.UpdatedAtField.Valid {
+		data[b.UpdatedAtField.String] = time.Now()
+	}
+	if b.SoftDeleteField.Valid {
+		data[b.SoftDeleteField.String] = nil
 	}
 }
 
@@ -85,8 +161,11 @@ func unmarshalBody(r *http.Request) (map[string]any, error) {
 	if err != nil {
 		return nil, err
 	}
+	// numbers are kept as json.Number, so they are parsed by the type of the field
 	var objmap map[string]any
-	err = json.Unmarshal(b.Bytes(), &objmap)
+	d := json.NewDecoder(b)
+	d.UseNumber()
+	err = d.Decode(&objmap)
 	return objmap, err
 }
 
@@ -2,12 +2,16 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/franciscoescher/gosimplerest/repository"
 	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 	"github.com/stoewer/go-strcase"
@@ -53,7 +57,7 @@ func TestCreateHandlerOK(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, response.Code)
 
-	dataInDB, _ := params.Repository.Find(params.Resource, bodyJson["uuid"])
+	dataInDB, _ := params.Repository.Find(context.Background(), params.Resource, bodyJson["uuid"], repository.FindOptions{})
 	dataOnlyInsertedFields := map[string]interface{}{
 		"first_name": dataInDB["first_name"],
 		"phone":      dataInDB["phone"],
@@ -88,3 +92,47 @@ func TestCreateHandlerBadRequest(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, response.Code)
 }
+
+func TestCreateHandlerTypedFields(t *testing.T) {
+	// Prepare the test
+	orders := resource.Resource{
+		Name:              "orders_test",
+		PrimaryKey:        "id",
+		AutoIncrementalPK: true,
+		Fields: map[string]resource.Field{
+			"id":    {Type: resource.TypeInt},
+			"total": {Type: resource.TypeDecimal},
+			"paid":  {Type: resource.TypeBool},
+		},
+	}
+	params := &GetHandlerFuncParams{Resource: &orders, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	route := "/" + strcase.KebabCase(orders.Table())
+
+	// Make the request
+	request, err := http.NewRequest(http.MethodPost, route, strings.NewReader(`{"total": 10.10, "paid": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := httptest.NewRecorder()
+	handler := http.HandlerFunc(CreateHandler(params))
+	handler.ServeHTTP(response, request)
+
+	// Make assertions
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"id": 1}`, response.Body.String())
+	dataInDB, err := params.Repository.Find(context.Background(), params.Resource, int64(1), repository.FindOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, json.Number("10.10"), dataInDB["total"])
+	assert.Equal(t, true, dataInDB["paid"])
+
+	// values that are not of the type of the field are rejected
+	request, err = http.NewRequest(http.MethodPost, route, strings.NewReader(`{"total": 10.10, "paid": "maybe"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "field paid is invalid for type bool"}`, response.Body.String())
+}
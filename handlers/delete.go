@@ -2,30 +2,34 @@ package handlers
 
 import (
 	"net/http"
+
+	"github.com/franciscoescher/gosimplerest/repository"
 )
 
 // DeleteHandler returns a handler for the DELETE method
 func DeleteHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := ReadParams(r, "id")
+		ctx, cancel := params.Resource.QueryContext(r.Context())
+		defer cancel()
 
-		// validates id
-		err := params.Resource.ValidateField(params.Validate, params.Resource.PrimaryKey, id)
+		// validates id, converted to the type of the primary key
+		id, err := params.Resource.ParseValue(params.Resource.PrimaryKey, ReadParams(r, "id"))
+		if err == nil {
+			err = params.Resource.ValidateField(params.Validate, params.Resource.PrimaryKey, id)
+		}
 		if err != nil {
 			params.Logger.Error(err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		err = params.Repository.Delete(params.Resource, id)
+		err = repository.WithTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+			return tx.Delete(ctx, params.Resource, id)
+		})
 		if err != nil {
-			if err.Error() == "no rows affected" {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			params.Logger.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, r, params, err)
 			return
 		}
+		syncIndex(ctx, params, id)
 	}
 }
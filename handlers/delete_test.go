@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/franciscoescher/gosimplerest/repository"
 	"github.com/franciscoescher/gosimplerest/repository/local"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
@@ -26,7 +28,7 @@ func TestDeleteHandler(t *testing.T) {
 		"deleted_at": nil,
 		"created_at": t1.Add(-time.Hour * 24),
 	}
-	_, _ = base.Repository.Insert(&testResource, data)
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
 
 	// Make the request
 	route := "/" + strcase.KebabCase(testResource.Table())
@@ -42,8 +44,9 @@ func TestDeleteHandler(t *testing.T) {
 	// Make assertions
 	assert.Equal(t, http.StatusOK, response.Code)
 
-	dataDB, _ := base.Repository.Find(&testResource, data["uuid"])
-	assert.Len(t, dataDB, 0)
+	// the resource uses soft deletes, so the row is kept with the deleted at field set
+	dataDB, _ := base.Repository.Find(context.Background(), &testResource, data["uuid"], repository.FindOptions{IncludeDeleted: true})
+	assert.NotNil(t, dataDB["deleted_at"])
 }
 
 func TestDeleteHandlerNotFound(t *testing.T) {
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+)
+
+// repositoryErrorStatus returns the http status of an error returned by the repository
+// and the message written to the client, empty for internal server errors
+func repositoryErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound, repository.ErrNotFound.Error()
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict, repository.ErrConflict.Error()
+	case errors.Is(err, repository.ErrConstraint):
+		return http.StatusUnprocessableEntity, repository.ErrConstraint.Error()
+	case errors.Is(err, repository.ErrInvalidQuery):
+		return http.StatusBadRequest, repository.ErrInvalidQuery.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	}
+	return http.StatusInternalServerError, ""
+}
+
+// writeRepositoryError writes the response of an error returned by the repository.
+// The error is always logged, but only the kind of the error is written to the client,
+// so details of the storage are not exposed.
+func writeRepositoryError(w http.ResponseWriter, r *http.Request, params *GetHandlerFuncParams, err error) {
+	params.Logger.Error(err)
+	status, msg := repositoryErrorStatus(err)
+	w.WriteHeader(status)
+	if msg == "" {
+		return
+	}
+	err = encodeJsonError(w, r, msg)
+	if err != nil {
+		params.Logger.Error(err)
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryErrorStatus(t *testing.T) {
+	cause := errors.New("driver error")
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("%w: no rows affected", repository.ErrNotFound), http.StatusNotFound},
+		{repository.NewError(repository.ErrConflict, cause), http.StatusConflict},
+		{repository.NewError(repository.ErrConstraint, cause), http.StatusUnprocessableEntity},
+		{repository.NewError(repository.ErrInvalidQuery, cause), http.StatusBadRequest},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{cause, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		status, _ := repositoryErrorStatus(tt.err)
+		assert.Equal(t, tt.status, status, tt.err.Error())
+	}
+}
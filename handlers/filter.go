@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/filterexpr"
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// splitFilterKey splits a query param in the field[op] format into its field and operator.
+// Returns false if the param is not in the format, being a plain field.
+func splitFilterKey(key string) (string, resource.Operator, bool) {
+	open := strings.IndexByte(key, '[')
+	if open <= 0 || !strings.HasSuffix(key, "]") {
+		return key, "", false
+	}
+	return key[:open], resource.Operator(key[open+1 : len(key)-1]), true
+}
+
+// readFilter reads a filter from the field, operator and value of a query param.
+// The values are parsed to the type of the field and validated, except like patterns,
+// which are not values of the field. The in and nin operators take comma separated values.
+func readFilter(params *GetHandlerFuncParams, field string, op resource.Operator, value string) (repository.Filter, error) {
+	f := repository.Filter{Field: field, Op: op}
+	if !op.Valid() {
+		return f, fmt.Errorf("%s is not a valid operator", op)
+	}
+	if !params.Resource.AllowsOperator(field, op) {
+		return f, fmt.Errorf("operator %s is not allowed for %s", op, field)
+	}
+
+	switch op {
+	case resource.OpNull:
+		null, err := strconv.ParseBool(value)
+		if err != nil {
+			return f, fmt.Errorf("%s[%s] must be a boolean", field, op)
+		}
+		f.Values = []any{null}
+		return f, nil
+	case resource.OpLike:
+		f.Values = []any{value}
+		return f, nil
+	}
+
+	values := []string{value}
+	if op == resource.OpIn || op == resource.OpNin {
+		values = strings.Split(value, ",")
+	}
+	for _, v := range values {
+		parsed, err := params.Resource.ParseValue(field, v)
+		if err == nil {
+			err = params.Resource.ValidateField(params.Validate, field, parsed)
+		}
+		if err != nil {
+			return f, fmt.Errorf("%s[%s] is invalid: %w", field, op, err)
+		}
+		f.Values = append(f.Values, parsed)
+	}
+	return f, nil
+}
+
+// filterParam is the query param with the boolean filter expression of the search route
+const filterParam = "filter"
+
+// readFilterExpr reads the filter query param, parsed by filterexpr.Parse,
+// returning nil if it is not set
+func readFilterExpr(r *http.Request, params *GetHandlerFuncParams) (repository.Expr, error) {
+	value := r.URL.Query().Get(filterParam)
+	if value == "" {
+		return nil, nil
+	}
+	return filterexpr.Parse(params.Resource, params.Validate, value)
+}
+
+// readWhere reads the query params other than the given ones as the values of searchable
+// fields, ORed for the same field, and the params in the field[op] format as filters.
+// The values are parsed to the types of the fields and validated.
+func readWhere(r *http.Request, params *GetHandlerFuncParams, other []string) (map[string][]any, []repository.Filter, error) {
+	values := r.URL.Query()
+	for _, param := range other {
+		values.Del(param)
+	}
+	where := make(map[string][]any, len(values))
+	var filters []repository.Filter
+	for key := range values {
+		// params in the field[op] format are filters with an operator
+		if field, op, ok := splitFilterKey(key); ok {
+			for _, v := range values[key] {
+				filter, err := readFilter(params, field, op, v)
+				if err != nil {
+					return nil, nil, err
+				}
+				filters = append(filters, filter)
+			}
+			continue
+		}
+		if !params.Resource.IsSearchable(key) {
+			return nil, nil, fmt.Errorf("%s is not searchable", key)
+		}
+		for _, v := range values[key] {
+			value, err := params.Resource.ParseValue(key, v)
+			if err == nil {
+				err = params.Resource.ValidateField(params.Validate, key, value)
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s is invalid: %w", key, err)
+			}
+			where[key] = append(where[key], value)
+		}
+	}
+	return where, filters, nil
+}
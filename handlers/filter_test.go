@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var carResource = resource.Resource{
+	Name:       "cars_test",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":             {Type: resource.TypeInt, Operators: []resource.Operator{resource.OpIn, resource.OpNin}},
+		"year":           {Type: resource.TypeInt, Validator: "min=1900", Operators: []resource.Operator{resource.OpGte, resource.OpLte}},
+		"price_per_hour": {Type: resource.TypeFloat, Operators: []resource.Operator{resource.OpLt}},
+		"license_plate":  {Type: resource.TypeString, Operators: []resource.Operator{resource.OpLike}},
+		"cancel_time":    {Type: resource.TypeTime, Operators: []resource.Operator{resource.OpNull}},
+	},
+}
+
+func TestSearchHandlerFilters(t *testing.T) {
+	// Prepare the test
+	base := &GetHandlerFuncParams{Resource: &carResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	cars := []map[string]any{
+		{"id": int64(1), "year": int64(2015), "price_per_hour": 15.0, "license_plate": "AB1234", "cancel_time": nil},
+		{"id": int64(2), "year": int64(2019), "price_per_hour": 18.5, "license_plate": "AB5678", "cancel_time": nil},
+		{"id": int64(3), "year": int64(2020), "price_per_hour": 25.0, "license_plate": "AB9999", "cancel_time": nil},
+		{"id": int64(4), "year": int64(2021), "price_per_hour": 12.0, "license_plate": "CD1234", "cancel_time": time.Now().UTC()},
+	}
+	for _, car := range cars {
+		_, err := base.Repository.Insert(context.Background(), &carResource, car)
+		require.NoError(t, err)
+	}
+
+	search := func(query string) (int, []int) {
+		request, err := http.NewRequest(http.MethodGet, "/cars-test?"+query, nil)
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		http.HandlerFunc(SearchHandler(base)).ServeHTTP(response, request)
+		ids := make([]int, 0)
+		if response.Code == http.StatusOK {
+			var rows []map[string]any
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+			for _, row := range rows {
+				ids = append(ids, int(row["id"].(float64)))
+			}
+		}
+		return response.Code, ids
+	}
+
+	// Make assertions
+	code, ids := search("year[gte]=2018&price_per_hour[lt]=20&license_plate[like]=" + url.QueryEscape("ab%") + "&cancel_time[null]=true")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []int{2}, ids)
+
+	_, ids = search("year[gte]=2016&year[lte]=2020")
+	assert.Equal(t, []int{2, 3}, ids)
+	_, ids = search("id[in]=1,3,4&cancel_time[null]=false")
+	assert.Equal(t, []int{4}, ids)
+	_, ids = search("id[nin]=1,3&year=2019")
+	assert.Equal(t, []int{2}, ids)
+
+	for _, query := range []string{
+		"year[between]=2018",
+		"year[gt]=2018",
+		"license_plate[gte]=AB",
+		"year[gte]=abc",
+		"year[gte]=1800",
+		"cancel_time[null]=maybe",
+		"missing[eq]=1",
+	} {
+		code, _ := search(query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
+
+func TestSearchHandlerShadowedFields(t *testing.T) {
+	// Prepare the test
+	votes := resource.Resource{
+		Name:       "votes",
+		PrimaryKey: "id",
+		Fields:     map[string]resource.Field{"id": {Type: resource.TypeInt}, "count": {Type: resource.TypeInt}, "sort": {Type: resource.TypeInt}},
+	}
+	params := &GetHandlerFuncParams{Resource: &votes, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	for i := int64(1); i <= 3; i++ {
+		_, err := params.Repository.Insert(context.Background(), &votes, map[string]any{"id": i, "count": i * 10, "sort": 4 - i})
+		require.NoError(t, err)
+	}
+	search := func(query string) []any {
+		request, err := http.NewRequest(http.MethodGet, "/votes?"+query, nil)
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		http.HandlerFunc(SearchHandler(params)).ServeHTTP(response, request)
+		require.Equal(t, http.StatusOK, response.Code, query)
+		var rows []map[string]any
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+		ids := make([]any, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row["id"])
+		}
+		return ids
+	}
+
+	// Make assertions
+	assert.Equal(t, []string{"count", "sort"}, ShadowedFields(&votes))
+	// the names alone are the params, and the operator format filters the fields
+	assert.Equal(t, []any{1.0, 2.0, 3.0}, search("count=true"))
+	assert.Equal(t, []any{2.0}, search("count[eq]=20"))
+	assert.Equal(t, []any{3.0}, search("sort[eq]=1"))
+	assert.Equal(t, []any{1.0}, search("filter="+url.QueryEscape("sort = 3")))
+}
+
+func TestSearchHandlerFilterExpr(t *testing.T) {
+	// Prepare the test
+	base := &GetHandlerFuncParams{Resource: &carResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	cars := []map[string]any{
+		{"id": int64(1), "year": int64(2015), "price_per_hour": 15.0, "license_plate": "AB1234", "cancel_time": nil},
+		{"id": int64(2), "year": int64(2019), "price_per_hour": 18.5, "license_plate": "CD5678", "cancel_time": nil},
+		{"id": int64(3), "year": int64(2020), "price_per_hour": 25.0, "license_plate": "CD9999", "cancel_time": time.Now().UTC()},
+	}
+	for _, car := range cars {
+		_, err := base.Repository.Insert(context.Background(), &carResource, car)
+		require.NoError(t, err)
+	}
+
+	search := func(filter string) (int, []int) {
+		request, err := http.NewRequest(http.MethodGet, "/cars-test?filter="+url.QueryEscape(filter), nil)
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		http.HandlerFunc(SearchHandler(base)).ServeHTTP(response, request)
+		ids := make([]int, 0)
+		if response.Code == http.StatusOK {
+			var rows []map[string]any
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+			for _, row := range rows {
+				ids = append(ids, int(row["id"].(float64)))
+			}
+		}
+		return response.Code, ids
+	}
+
+	// Make assertions
+	code, ids := search("license_plate like 'ab%' or (year >= 2018 and cancel_time is not null)")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []int{1, 3}, ids)
+	_, ids = search("not id in (1, 2)")
+	assert.Equal(t, []int{3}, ids)
+
+	for _, filter := range []string{
+		"year > 2018",
+		"year >= 1800",
+		"missing = 1",
+		"year >= 2018 and",
+		"(year >= 2018",
+		"license_plate like 'ab%",
+	} {
+		code, _ := search(filter)
+		assert.Equal(t, http.StatusBadRequest, code, filter)
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// includeParam is the query param with the relations embedded in the rows of the retrieve and search routes
+const includeParam = "include"
+
+// readInclude reads the include query param, a comma separated list of relations of the resource
+func readInclude(r *http.Request, params *GetHandlerFuncParams) ([]string, error) {
+	include := readList(r, includeParam)
+	included := make(map[string]bool, len(include))
+	for _, name := range include {
+		if _, ok := params.Includes[name]; !ok {
+			return nil, fmt.Errorf("%s is not a relation", name)
+		}
+		if included[name] {
+			return nil, fmt.Errorf("%s is included more than once", name)
+		}
+		included[name] = true
+	}
+	return include, nil
+}
+
+// includeFields returns the fields read for the fields param and the included relations,
+// adding the foreign keys of the belongs to relations, which reference their rows.
+// It returns nil, so all the fields are read, if the fields param is not set.
+func includeFields(params *GetHandlerFuncParams, fields []string, include []string) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+	read := append([]string{}, fields...)
+	for _, name := range include {
+		if rel := params.Includes[name].Relation; rel.Kind == resource.BelongsTo {
+			read = append(read, rel.ForeignKey)
+		}
+	}
+	return read
+}
+
+// shownFields returns the fields kept in the rows by omitFields, which are the fields
+// of the fields param and the included relations, or nil if the fields param is not set
+func shownFields(fields []string, include []string) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+	return append(append([]string{}, fields...), include...)
+}
+
+// embed sets in the rows the related rows of each included relation, under its name: the row of
+// belongs to relations, or nil, and the rows of the others, ordered by their primary keys. The
+// rows of each relation are read with searchIn, twice for many to many relations, soft
+// deleted rows are never embedded, and hidden fields are omitted as in the routes of their resource.
+func embed(ctx context.Context, repo repository.RepositoryInterface, params *GetHandlerFuncParams, rows []map[string]any, include []string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	for _, name := range include {
+		inc := params.Includes[name]
+		var err error
+		switch inc.Relation.Kind {
+		case resource.BelongsTo:
+			err = embedBelongsTo(ctx, repo, name, inc, rows)
+		case resource.HasMany:
+			err = embedHasMany(ctx, repo, params.Resource, name, inc, rows)
+		default:
+			err = embedManyToMany(ctx, repo, params.Resource, name, inc, rows)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func embedBelongsTo(ctx context.Context, repo repository.RepositoryInterface, name string, inc Include, rows []map[string]any) error {
+	related, err := searchIn(ctx, repo, inc.Resource, inc.Resource.PrimaryKey, column(rows, inc.Relation.ForeignKey))
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]map[string]any, len(related))
+	for _, row := range related {
+		byKey[relationKey(row[inc.Resource.PrimaryKey])] = row
+	}
+	for _, row := range rows {
+		var embedded map[string]any
+		if key := row[inc.Relation.ForeignKey]; key != nil {
+			embedded = byKey[relationKey(key)]
+		}
+		row[name] = embedded
+	}
+	omitFields(related, inc.Resource, nil)
+	return nil
+}
+
+func embedHasMany(ctx context.Context, repo repository.RepositoryInterface, b *resource.Resource, name string, inc Include, rows []map[string]any) error {
+	related, err := searchIn(ctx, repo, inc.Resource, inc.Relation.ForeignKey, column(rows, b.PrimaryKey))
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string][]map[string]any, len(rows))
+	for _, row := range related {
+		key := relationKey(row[inc.Relation.ForeignKey])
+		byKey[key] = append(byKey[key], row)
+	}
+	for _, row := range rows {
+		embedded := byKey[relationKey(row[b.PrimaryKey])]
+		if embedded == nil {
+			embedded = make([]map[string]any, 0)
+		}
+		row[name] = embedded
+	}
+	omitFields(related, inc.Resource, nil)
+	return nil
+}
+
+func embedManyToMany(ctx context.Context, repo repository.RepositoryInterface, b *resource.Resource, name string, inc Include, rows []map[string]any) error {
+	joins, err := searchIn(ctx, repo, inc.Through, inc.Relation.ForeignKey, column(rows, b.PrimaryKey))
+	if err != nil {
+		return err
+	}
+	related, err := searchIn(ctx, repo, inc.Resource, inc.Resource.PrimaryKey, column(joins, inc.Relation.OtherKey))
+	if err != nil {
+		return err
+	}
+	// the keys of the rows linked to each related row
+	parents := make(map[string][]string, len(related))
+	seen := make(map[[2]string]bool, len(joins))
+	for _, join := range joins {
+		link := [2]string{relationKey(join[inc.Relation.ForeignKey]), relationKey(join[inc.Relation.OtherKey])}
+		if !seen[link] {
+			seen[link] = true
+			parents[link[1]] = append(parents[link[1]], link[0])
+		}
+	}
+	linked := make(map[string][]map[string]any, len(rows))
+	for _, r := range related {
+		for _, key := range parents[relationKey(r[inc.Resource.PrimaryKey])] {
+			linked[key] = append(linked[key], r)
+		}
+	}
+	for _, row := range rows {
+		embedded := linked[relationKey(row[b.PrimaryKey])]
+		if embedded == nil {
+			embedded = make([]map[string]any, 0)
+		}
+		row[name] = embedded
+	}
+	omitFields(related, inc.Resource, nil)
+	return nil
+}
+
+// inChunkSize is the maximum number of values of the in filters of searchIn, which are bound
+// to the statements of the sql repositories, whose number of parameters is limited
+const inChunkSize = 1000
+
+// searchIn returns the rows of the resource whose field is one of the values, ordered by their
+// primary keys, or no rows if there are no values. There is a search for each chunk of
+// inChunkSize values, and the rows of several chunks are merged in the order of memquery.
+func searchIn(ctx context.Context, repo repository.RepositoryInterface, b *resource.Resource, field string, values []any) ([]map[string]any, error) {
+	var rows []map[string]any
+	for start := 0; start < len(values); start += inChunkSize {
+		end := start + inChunkSize
+		if end > len(values) {
+			end = len(values)
+		}
+		chunk, err := repo.Search(ctx, b, repository.Query{Filters: []repository.Filter{{Field: field, Op: resource.OpIn, Values: values[start:end]}}})
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, chunk...)
+	}
+	if len(values) > inChunkSize {
+		memquery.Sort(rows, repository.Query{}.Order(b.PrimaryKey))
+	}
+	return rows, nil
+}
+
+// column returns the distinct values of the field of the rows that are not null
+func column(rows []map[string]any, field string) []any {
+	values := make([]any, 0, len(rows))
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		v := row[field]
+		if v == nil || seen[relationKey(v)] {
+			continue
+		}
+		seen[relationKey(v)] = true
+		values = append(values, v)
+	}
+	return values
+}
+
+// relationKey returns the string representation of a key, so foreign keys match
+// the primary keys they reference even if their fields have different types
+func relationKey(v any) string {
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInclude(t *testing.T) {
+	// Prepare the test
+	repo := local.NewRepository()
+	ctx := context.Background()
+	for _, o := range []string{"a", "b"} {
+		_, err := repo.Insert(ctx, &ownerResource, map[string]any{"id": o, "name": o})
+		require.NoError(t, err)
+	}
+	for _, v := range []string{"x", "y"} {
+		_, err := repo.Insert(ctx, &vehicleResource, map[string]any{"id": v, "plate": v})
+		require.NoError(t, err)
+	}
+	for i, ov := range [][2]string{{"a", "y"}, {"a", "x"}} {
+		_, err := repo.Insert(ctx, &ownerVehicleResource, map[string]any{"id": string(rune('1' + i)), "owner_id": ov[0], "vehicle_id": ov[1]})
+		require.NoError(t, err)
+	}
+	for id, owner := range map[string]any{"1": "a", "2": "a", "3": nil} {
+		_, err := repo.Insert(ctx, &eventResource, map[string]any{"id": id, "owner_id": owner, "hours": int64(1)})
+		require.NoError(t, err)
+	}
+	owners := &GetHandlerFuncParams{
+		Resource: &ownerResource, Logger: logrus.New(), Repository: repo, Validate: validator.New(),
+		Includes: map[string]Include{
+			"rent_events": {Relation: resource.Relation{Kind: resource.HasMany, Resource: "rent_events", ForeignKey: "owner_id"}, Resource: &eventResource},
+			"vehicles": {
+				Relation: resource.Relation{Kind: resource.ManyToMany, Resource: "vehicles", ForeignKey: "owner_id", Through: "owner_vehicles", OtherKey: "vehicle_id"},
+				Resource: &vehicleResource,
+				Through:  &ownerVehicleResource,
+			},
+		},
+	}
+	events := &GetHandlerFuncParams{
+		Resource: &eventResource, Logger: logrus.New(), Repository: repo, Validate: validator.New(),
+		Includes: map[string]Include{
+			"owner": {Relation: resource.Relation{Kind: resource.BelongsTo, Resource: "owners", ForeignKey: "owner_id"}, Resource: &ownerResource},
+		},
+	}
+	serve := func(h http.HandlerFunc, path, id, query string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodGet, path+"?"+query, nil)
+		require.NoError(t, err)
+		if id != "" {
+			request = GetRequestWithParams(request, map[string]string{"id": id})
+		}
+		response := httptest.NewRecorder()
+		h.ServeHTTP(response, request)
+		return response
+	}
+
+	// Make assertions
+	response := serve(SearchHandler(owners), "/owners", "", "include=rent_events,vehicles&fields=name")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `[
+		{
+			"id": "a", "name": "a",
+			"rent_events": [{"id": "1", "owner_id": "a", "hours": 1}, {"id": "2", "owner_id": "a", "hours": 1}],
+			"vehicles": [{"id": "x", "plate": "x"}, {"id": "y", "plate": "y"}]
+		},
+		{"id": "b", "name": "b", "rent_events": [], "vehicles": []}
+	]`, response.Body.String())
+
+	// the foreign keys of belongs to relations are read, but only returned if requested
+	response = serve(SearchHandler(events), "/rent_events", "", "include=owner&fields=hours")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `[
+		{"id": "1", "hours": 1, "owner": {"id": "a", "name": "a"}},
+		{"id": "2", "hours": 1, "owner": {"id": "a", "name": "a"}},
+		{"id": "3", "hours": 1, "owner": null}
+	]`, response.Body.String())
+
+	response = serve(RetrieveHandler(events), "/rent_events/1", "1", "include=owner")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"id": "1", "owner_id": "a", "hours": 1, "owner": {"id": "a", "name": "a"}}`, response.Body.String())
+
+	response = serve(RetrieveHandler(owners), "/owners/a", "a", "include=vehicles")
+	require.Equal(t, http.StatusOK, response.Code)
+	var row map[string]any
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &row))
+	assert.Len(t, row["vehicles"], 2)
+
+	for _, query := range []string{"include=missing", "include=owner,owner"} {
+		response = serve(SearchHandler(events), "/rent_events", "", query)
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+		response = serve(RetrieveHandler(events), "/rent_events/1", "1", query)
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+	}
+}
+
+func TestIncludeHiddenFields(t *testing.T) {
+	// Prepare the test
+	owners := ownerResource
+	owners.Fields = map[string]resource.Field{"id": {}, "name": {}, "password": {Hidden: true}}
+	repo := local.NewRepository()
+	ctx := context.Background()
+	_, err := repo.Insert(ctx, &owners, map[string]any{"id": "a", "name": "a", "password": "secret"})
+	require.NoError(t, err)
+	_, err = repo.Insert(ctx, &eventResource, map[string]any{"id": "1", "owner_id": "a", "hours": int64(1)})
+	require.NoError(t, err)
+	params := &GetHandlerFuncParams{
+		Resource: &eventResource, Logger: logrus.New(), Repository: repo, Validate: validator.New(),
+		Includes: map[string]Include{
+			"owner": {Relation: resource.Relation{Kind: resource.BelongsTo, Resource: "owners", ForeignKey: "owner_id"}, Resource: &owners},
+		},
+	}
+	ownerParams := &GetHandlerFuncParams{Resource: &owners, Logger: logrus.New(), Repository: repo, Validate: validator.New()}
+	serve := func(h http.HandlerFunc, path, id, query string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodGet, path+"?"+query, nil)
+		require.NoError(t, err)
+		if id != "" {
+			request = GetRequestWithParams(request, map[string]string{"id": id})
+		}
+		response := httptest.NewRecorder()
+		h.ServeHTTP(response, request)
+		return response
+	}
+
+	// Make assertions
+	// hidden fields of the related rows are omitted, as in the routes of the related resource
+	response := serve(SearchHandler(params), "/rent_events", "", "include=owner")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `[{"id": "1", "owner_id": "a", "hours": 1, "owner": {"id": "a", "name": "a"}}]`, response.Body.String())
+
+	response = serve(RetrieveHandler(params), "/rent_events/1", "1", "include=owner")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"id": "1", "owner_id": "a", "hours": 1, "owner": {"id": "a", "name": "a"}}`, response.Body.String())
+
+	response = serve(RetrieveHandler(ownerParams), "/owners/a", "a", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"id": "a", "name": "a"}`, response.Body.String())
+
+	// hidden fields can not be selected or filtered
+	for _, query := range []string{"fields=password", "password=secret"} {
+		response = serve(SearchHandler(ownerParams), "/owners", "", query)
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+	}
+}
+
+func TestIncludeChunks(t *testing.T) {
+	// Prepare the test
+	repo := local.NewRepository()
+	ctx := context.Background()
+	n := inChunkSize + 1
+	vehicles := make([]any, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		id := fmt.Sprintf("%05d", i)
+		_, err := repo.Insert(ctx, &ownerResource, map[string]any{"id": id, "name": id})
+		require.NoError(t, err)
+		_, err = repo.Insert(ctx, &vehicleResource, map[string]any{"id": id, "plate": id})
+		require.NoError(t, err)
+		_, err = repo.Insert(ctx, &eventResource, map[string]any{"id": id, "owner_id": id, "hours": int64(1)})
+		require.NoError(t, err)
+		_, err = repo.Insert(ctx, &ownerVehicleResource, map[string]any{"id": id, "owner_id": "00000", "vehicle_id": id})
+		require.NoError(t, err)
+		vehicles = append([]any{id}, vehicles...)
+	}
+	params := &GetHandlerFuncParams{
+		Resource: &eventResource, Logger: logrus.New(), Repository: repo, Validate: validator.New(),
+		Includes: map[string]Include{
+			"owner": {Relation: resource.Relation{Kind: resource.BelongsTo, Resource: "owners", ForeignKey: "owner_id"}, Resource: &ownerResource},
+		},
+	}
+	owners := &GetHandlerFuncParams{
+		Resource: &ownerResource, Logger: logrus.New(), Repository: repo, Validate: validator.New(),
+		Includes: map[string]Include{
+			"vehicles": {
+				Relation: resource.Relation{Kind: resource.ManyToMany, Resource: "vehicles", ForeignKey: "owner_id", Through: "owner_vehicles", OtherKey: "vehicle_id"},
+				Resource: &vehicleResource,
+				Through:  &ownerVehicleResource,
+			},
+		},
+	}
+
+	// Make assertions
+	// the related rows of more keys than a chunk are read with several searches
+	request, err := http.NewRequest(http.MethodGet, "/rent_events?include=owner", nil)
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	SearchHandler(params).ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+	var events []map[string]any
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &events))
+	require.Len(t, events, n)
+	for _, event := range events {
+		assert.Equal(t, map[string]any{"id": event["owner_id"], "name": event["owner_id"]}, event["owner"])
+	}
+
+	// and merged in the order of their primary keys
+	request, err = http.NewRequest(http.MethodGet, "/owners/00000?include=vehicles", nil)
+	require.NoError(t, err)
+	request = GetRequestWithParams(request, map[string]string{"id": "00000"})
+	response = httptest.NewRecorder()
+	RetrieveHandler(owners).ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+	var owner struct {
+		Vehicles []map[string]any `json:"vehicles"`
+	}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &owner))
+	ids := make([]any, len(owner.Vehicles))
+	for i, v := range owner.Vehicles {
+		ids[i] = v["id"]
+	}
+	assert.Equal(t, vehicles, ids)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/searchindex"
+)
+
+// indexParams are the query params of the search route that can be combined
+// with the q param when it is matched by the search index
+var indexParams = []string{textParam, fieldsParam, includeParam, limitParam, offsetParam, countParam}
+
+// syncIndex updates the row with the primary key in the search index, if there is one,
+// after it is written to the repository. The row is read again, so partial updates
+// are indexed whole, and rows that are deleted or soft deleted are removed.
+// Errors are logged, since the row is already written, and the index can be rebuilt.
+func syncIndex(ctx context.Context, params *GetHandlerFuncParams, id any) {
+	if params.SearchIndex == nil || len(params.Resource.FullTextFields()) == 0 {
+		return
+	}
+	row, err := params.Repository.Find(ctx, params.Resource, id, repository.FindOptions{})
+	if err == nil && len(row) == 0 {
+		err = params.SearchIndex.Delete(ctx, params.Resource, id)
+	} else if err == nil {
+		err = params.SearchIndex.Index(ctx, params.Resource, row)
+	}
+	if err != nil {
+		params.Logger.Error(fmt.Errorf("search index of %s %v: %w", params.Resource.Name, id, err))
+	}
+}
+
+// usesIndex returns true if the q param of the search route is matched by the search index
+func usesIndex(params *GetHandlerFuncParams, text string) bool {
+	return params.SearchIndex != nil && len(repository.TextTerms(text)) > 0
+}
+
+// indexSearch writes the rows of the search route matched by the search index, ordered by
+// relevance, as read from the repository. Each row has its score in the _score key, and
+// the fragments of the fields that matched in the _highlights key. Only the limit, offset,
+// count, fields and include params can be combined with it.
+func indexSearch(ctx context.Context, w http.ResponseWriter, r *http.Request, params *GetHandlerFuncParams, text string, fields []string, include []string, pg page) {
+	values := r.URL.Query()
+	for _, param := range indexParams {
+		values.Del(param)
+	}
+	if len(values) > 0 {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.WriteHeader(http.StatusBadRequest)
+		err := encodeJsonError(w, r, fmt.Sprintf("%s can not be used with %s", strings.Join(keys, ", "), textParam))
+		if err != nil {
+			params.Logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// links to the other pages use offsets, since the hits have no cursor
+	pg.offsetMode = true
+	q := searchindex.Query{Text: text, Offset: pg.offset}
+	if pg.limit > 0 {
+		q.Limit = pg.limit + 1
+	}
+	result, err := params.SearchIndex.Search(ctx, params.Resource, q)
+	if err != nil {
+		writeRepositoryError(w, r, params, err)
+		return
+	}
+
+	// the next page is read from the hits, before the hits whose rows are gone,
+	// because the index is behind the repository, are left out
+	hits := result.Hits
+	more := pg.limit > 0 && len(hits) > pg.limit
+	if more {
+		hits = hits[:pg.limit]
+	}
+	var rows []map[string]any
+	var found []searchindex.Hit
+	err = repository.WithReadTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+		rows = make([]map[string]any, 0, len(hits))
+		found = make([]searchindex.Hit, 0, len(hits))
+		for _, hit := range hits {
+			row, err := tx.Find(ctx, params.Resource, hit.ID, repository.FindOptions{Fields: includeFields(params, fields, include)})
+			if err != nil {
+				return err
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+				found = append(found, hit)
+			}
+		}
+		return embed(ctx, tx, params, rows, include)
+	})
+	if err != nil {
+		writeRepositoryError(w, r, params, err)
+		return
+	}
+
+	links, err := pg.links(r, params.Resource, rows, more)
+	if err != nil {
+		params.Logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if links != "" {
+		w.Header().Set("Link", links)
+	}
+	omitFields(rows, params.Resource, shownFields(fields, include))
+	if pg.count {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(result.Total, 10))
+	}
+	if len(rows) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	for i, row := range rows {
+		row["_score"] = found[i].Score
+		if len(found[i].Highlights) > 0 {
+			row["_highlights"] = found[i].Highlights
+		}
+	}
+
+	err = encodeJson(w, r, rows)
+	if err != nil {
+		params.Logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
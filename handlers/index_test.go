@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/franciscoescher/gosimplerest/searchindex/bleveindex"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v3"
+)
+
+var bookResource = resource.Resource{
+	Name:              "books_test",
+	PrimaryKey:        "id",
+	AutoIncrementalPK: true,
+	Fields: map[string]resource.Field{
+		"id":         {Type: resource.TypeInt},
+		"title":      {Type: resource.TypeString, FullText: true},
+		"author":     {Type: resource.TypeString, FullText: true},
+		"deleted_at": {Type: resource.TypeTime},
+	},
+	SoftDeleteField: null.NewString("deleted_at", true),
+}
+
+func TestSearchHandlerIndex(t *testing.T) {
+	// Prepare the test
+	index := bleveindex.New("")
+	defer index.Close()
+	base := &GetHandlerFuncParams{Resource: &bookResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New(), SearchIndex: index}
+
+	send := func(method string, handler http.HandlerFunc, body map[string]any, id string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		request, err := http.NewRequest(method, "/books-test", bytes.NewBuffer(data))
+		require.NoError(t, err)
+		if id != "" {
+			request = GetRequestWithParams(request, map[string]string{"id": id})
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+	for _, book := range []map[string]any{
+		{"title": "Dom Casmurro", "author": "Machado de Assis"},
+		{"title": "Memorias Postumas", "author": "Machado de Assis"},
+		{"title": "O Cortico", "author": "Aluisio Azevedo"},
+	} {
+		response := send(http.MethodPost, CreateHandler(base), book, "")
+		require.Equal(t, http.StatusOK, response.Code)
+	}
+
+	search := func(query string) (*httptest.ResponseRecorder, []map[string]any) {
+		request, err := http.NewRequest(http.MethodGet, "/books-test?"+query, nil)
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		http.HandlerFunc(SearchHandler(base)).ServeHTTP(response, request)
+		var rows []map[string]any
+		if response.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+		}
+		return response, rows
+	}
+	titles := func(rows []map[string]any) []string {
+		titles := make([]string, len(rows))
+		for i, row := range rows {
+			titles[i], _ = row["title"].(string)
+		}
+		return titles
+	}
+
+	// Make assertions
+	response, rows := search("q=" + url.QueryEscape("machdo"))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.ElementsMatch(t, []string{"Dom Casmurro", "Memorias Postumas"}, titles(rows))
+	assert.Greater(t, rows[0]["_score"], 0.0)
+	assert.Equal(t, map[string]any{"author": []any{"<mark>Machado</mark> de Assis"}}, rows[0]["_highlights"])
+
+	response, rows = search("q=machado&limit=1&count=true&fields=author")
+	assert.Len(t, rows, 1)
+	assert.NotContains(t, rows[0], "title")
+	assert.Equal(t, "2", response.Header().Get("X-Total-Count"))
+	assert.Contains(t, response.Header().Get("Link"), "offset=1")
+
+	// the index follows the updates and deletes
+	response = send(http.MethodPatch, UpdateHandler(base), map[string]any{"id": 3, "author": "Machado"}, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	response = send(http.MethodDelete, DeleteHandler(base), nil, "1")
+	require.Equal(t, http.StatusOK, response.Code)
+	_, rows = search("q=machado")
+	assert.ElementsMatch(t, []string{"O Cortico", "Memorias Postumas"}, titles(rows))
+	response = send(http.MethodPost, RestoreHandler(base), nil, "1")
+	require.Equal(t, http.StatusOK, response.Code)
+	_, rows = search("q=casmurro")
+	assert.Equal(t, []string{"Dom Casmurro"}, titles(rows))
+
+	// the next page is linked from the hits, even when the rows of some hits are gone
+	require.NoError(t, index.Index(context.Background(), &bookResource, map[string]any{"id": int64(99), "author": "Machado"}))
+	response, rows = search("q=machado&limit=3")
+	assert.Len(t, rows, 2)
+	assert.Contains(t, response.Header().Get("Link"), "offset=3")
+
+	// other params are matched by the repository, so they can not be combined with the index
+	response, _ = search("q=machado&sort=id")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	response, _ = search("q=machado&title=O+Cortico")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	response, _ = search("q=zzzzzz")
+	assert.Equal(t, http.StatusNoContent, response.Code)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// query params of the pagination of the search route
+const (
+	limitParam  = "limit"
+	offsetParam = "offset"
+	cursorParam = "cursor"
+	countParam  = "count"
+)
+
+// page is the pagination of the search route, read from the query params
+type page struct {
+	// limit is the number of rows of the page, if 0, all rows are returned
+	limit  int
+	offset int
+	// offsetMode is set when the offset param is given, so the links to the
+	// other pages use offsets instead of cursors
+	offsetMode bool
+	cursor     *repository.Cursor
+	// sort is the order of the search, whose values are kept in the cursors
+	sort []repository.Sort
+	// count writes the number of rows matching the search in the X-Total-Count header
+	count bool
+}
+
+// cursorToken is the content of the cursor param, encoded as base64 json
+// so clients do not rely on its format
+type cursorToken struct {
+	Key    any   `json:"k"`
+	Values []any `json:"v,omitempty"`
+	Before bool  `json:"b,omitempty"`
+}
+
+// readPage reads the pagination params of the search route,
+// bounded by the page sizes of the resource
+func readPage(r *http.Request, b *resource.Resource, sort []repository.Sort) (page, error) {
+	values := r.URL.Query()
+	p := page{limit: b.DefaultPageSize, sort: sort}
+	if p.limit <= 0 {
+		p.limit = b.MaxPageSize
+	}
+	if v := values.Get(limitParam); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return p, fmt.Errorf("%s must be a positive integer", limitParam)
+		}
+		p.limit = limit
+	}
+	if b.MaxPageSize > 0 && p.limit > b.MaxPageSize {
+		p.limit = b.MaxPageSize
+	}
+	if v := values.Get(offsetParam); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return p, fmt.Errorf("%s must be a non negative integer", offsetParam)
+		}
+		p.offset, p.offsetMode = offset, true
+	}
+	if v := values.Get(cursorParam); v != "" {
+		if p.offsetMode {
+			return p, fmt.Errorf("%s and %s can not be used together", offsetParam, cursorParam)
+		}
+		cursor, err := decodeCursor(b, v, sort)
+		if err != nil {
+			return p, fmt.Errorf("%s is invalid", cursorParam)
+		}
+		p.cursor = cursor
+	}
+	if v := values.Get(countParam); v != "" {
+		count, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, fmt.Errorf("%s must be a boolean", countParam)
+		}
+		p.count = count
+	}
+	return p, nil
+}
+
+// apply sets the pagination of the query. One row more than the limit
+// is read, to know if there is another page.
+func (p page) apply(q *repository.Query) {
+	q.Offset = p.offset
+	q.Cursor = p.cursor
+	if p.limit > 0 {
+		q.Limit = p.limit + 1
+	}
+}
+
+// trim removes the extra row read because of apply, returning if there are
+// more rows after the page, or before it when paginating backwards
+func (p page) trim(rows []map[string]any) ([]map[string]any, bool) {
+	if p.limit <= 0 || len(rows) <= p.limit {
+		return rows, false
+	}
+	if p.backwards() {
+		return rows[1:], true
+	}
+	return rows[:p.limit], true
+}
+
+// backwards returns true if the page is read before a cursor
+func (p page) backwards() bool {
+	return p.cursor != nil && p.cursor.Before
+}
+
+// links returns the value of the Link header, with the next and prev pages
+// of the rows, or an empty string if the search is not paginated
+func (p page) links(r *http.Request, b *resource.Resource, rows []map[string]any, more bool) (string, error) {
+	if p.limit <= 0 {
+		return "", nil
+	}
+	links := make([]string, 0, 2)
+	if p.offsetMode {
+		if more {
+			links = append(links, p.link(r, "next", offsetParam, strconv.Itoa(p.offset+p.limit)))
+		}
+		if p.offset > 0 {
+			prev := p.offset - p.limit
+			if prev < 0 {
+				prev = 0
+			}
+			links = append(links, p.link(r, "prev", offsetParam, strconv.Itoa(prev)))
+		}
+		return strings.Join(links, ", "), nil
+	}
+
+	// the cursors point to the first and last rows of the page, or to the
+	// cursor of the request when the page is empty
+	cursorLink := func(rel string, row int, before bool) error {
+		token := cursorToken{Before: before}
+		if len(rows) > 0 {
+			token.Key = rows[row][b.PrimaryKey]
+			for _, s := range p.sort {
+				token.Values = append(token.Values, rows[row][s.Field])
+			}
+		} else {
+			token.Key, token.Values = p.cursor.Key, p.cursor.Values
+		}
+		cursor, err := encodeCursor(token)
+		if err != nil {
+			return err
+		}
+		links = append(links, p.link(r, rel, cursorParam, cursor))
+		return nil
+	}
+	if more || p.backwards() {
+		if err := cursorLink("next", len(rows)-1, false); err != nil {
+			return "", err
+		}
+	}
+	if (more && p.backwards()) || (p.cursor != nil && !p.backwards()) {
+		if err := cursorLink("prev", 0, true); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(links, ", "), nil
+}
+
+// link returns a link to another page, with the query params of the request
+// and the given offset or cursor param
+func (p page) link(r *http.Request, rel string, param string, value string) string {
+	values := r.URL.Query()
+	values.Del(offsetParam)
+	values.Del(cursorParam)
+	values.Set(limitParam, strconv.Itoa(p.limit))
+	values.Set(param, value)
+	u := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+	return `<` + u.String() + `>; rel="` + rel + `"`
+}
+
+// encodeCursor returns the value of the cursor param of a position
+func encodeCursor(token cursorToken) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor reads the cursor param, parsing its key and values to the types
+// of the primary key and of the sort fields
+func decodeCursor(b *resource.Resource, value string, sort []repository.Sort) (*repository.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var token cursorToken
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	err = d.Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	if token.Key == nil {
+		return nil, errors.New("cursor without key")
+	}
+	if len(token.Values) != len(sort) {
+		return nil, errors.New("cursor of another sort")
+	}
+	key, err := b.ParseValue(b.PrimaryKey, token.Key)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(sort))
+	for i, s := range sort {
+		if token.Values[i] == nil {
+			continue
+		}
+		values[i], err = b.ParseValue(s.Field, token.Values[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &repository.Cursor{Key: key, Values: values, Before: token.Before}, nil
+}
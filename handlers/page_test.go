@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pageResource = resource.Resource{
+	Name:       "pages_test",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":   {Type: resource.TypeInt},
+		"name": {Sortable: true},
+		"note": {},
+	},
+	MaxPageSize: 3,
+}
+
+// linkRegexp matches the links of a Link header
+var linkRegexp = regexp.MustCompile(`<([^>]*)>; rel="(\w+)"`)
+
+// searchPage makes a search request, returning the ids of the rows and the links of the response
+func searchPage(t *testing.T, base *GetHandlerFuncParams, route string) (*httptest.ResponseRecorder, []int, map[string]string) {
+	request, err := http.NewRequest(http.MethodGet, route, nil)
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	http.HandlerFunc(SearchHandler(base)).ServeHTTP(response, request)
+
+	ids := make([]int, 0)
+	if response.Code == http.StatusOK {
+		var rows []map[string]any
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+		for _, row := range rows {
+			ids = append(ids, int(row["id"].(float64)))
+		}
+	}
+	links := make(map[string]string)
+	for _, m := range linkRegexp.FindAllStringSubmatch(response.Header().Get("Link"), -1) {
+		links[m[2]] = m[1]
+	}
+	return response, ids, links
+}
+
+func newPageTest(t *testing.T) *GetHandlerFuncParams {
+	base := &GetHandlerFuncParams{Resource: &pageResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	for i := 1; i <= 5; i++ {
+		_, err := base.Repository.Insert(context.Background(), &pageResource, map[string]any{"id": int64(i), "name": "Fulano"})
+		require.NoError(t, err)
+	}
+	return base
+}
+
+func TestSearchHandlerCursorPagination(t *testing.T) {
+	base := newPageTest(t)
+
+	response, ids, links := searchPage(t, base, "/pages-test?name=Fulano&limit=2&count=true")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, []int{1, 2}, ids)
+	assert.Equal(t, "5", response.Header().Get("X-Total-Count"))
+	assert.NotContains(t, links, "prev")
+
+	_, ids, links = searchPage(t, base, links["next"])
+	assert.Equal(t, []int{3, 4}, ids)
+
+	_, ids, last := searchPage(t, base, links["next"])
+	assert.Equal(t, []int{5}, ids)
+	assert.NotContains(t, last, "next")
+
+	_, ids, links = searchPage(t, base, links["prev"])
+	assert.Equal(t, []int{1, 2}, ids)
+	assert.NotContains(t, links, "prev")
+
+	// the links keep the other query params
+	assert.Contains(t, links["next"], "name=Fulano")
+	assert.Contains(t, links["next"], "count=true")
+}
+
+func TestSearchHandlerOffsetPagination(t *testing.T) {
+	base := newPageTest(t)
+
+	_, ids, links := searchPage(t, base, "/pages-test?limit=2&offset=1")
+	assert.Equal(t, []int{2, 3}, ids)
+	assert.Equal(t, "/pages-test?limit=2&offset=3", links["next"])
+	assert.Equal(t, "/pages-test?limit=2&offset=0", links["prev"])
+
+	_, ids, links = searchPage(t, base, links["next"])
+	assert.Equal(t, []int{4, 5}, ids)
+	assert.NotContains(t, links, "next")
+}
+
+func TestSearchHandlerPageSize(t *testing.T) {
+	base := newPageTest(t)
+
+	// the maximum page size is used when there is no default, and bounds the limit
+	_, ids, _ := searchPage(t, base, "/pages-test")
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	_, ids, _ = searchPage(t, base, "/pages-test?limit=10")
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	unbounded := pageResource
+	unbounded.MaxPageSize = 0
+	base.Resource = &unbounded
+	_, ids, links := searchPage(t, base, "/pages-test")
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+	assert.Len(t, links, 0)
+}
+
+func TestSearchHandlerPaginationBadRequest(t *testing.T) {
+	base := newPageTest(t)
+
+	for _, query := range []string{"limit=0", "limit=a", "offset=-1", "cursor=abc", "count=maybe", "offset=1&cursor=eyJrIjoxfQ"} {
+		response, _, _ := searchPage(t, base, "/pages-test?"+query)
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+	}
+}
+
+func TestSearchHandlerSort(t *testing.T) {
+	base := &GetHandlerFuncParams{Resource: &pageResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	for i, name := range []string{"Fulano", "Ciclano", "Beltrano", "Ciclano", "Fulano"} {
+		_, err := base.Repository.Insert(context.Background(), &pageResource, map[string]any{"id": int64(i + 1), "name": name})
+		require.NoError(t, err)
+	}
+
+	// ties are ordered by the primary key
+	_, ids, links := searchPage(t, base, "/pages-test?sort=-name&limit=2")
+	assert.Equal(t, []int{1, 5}, ids)
+
+	_, ids, links = searchPage(t, base, links["next"])
+	assert.Equal(t, []int{2, 4}, ids)
+	assert.Contains(t, links["next"], "sort=-name")
+
+	_, ids, _ = searchPage(t, base, links["next"])
+	assert.Equal(t, []int{3}, ids)
+
+	_, ids, _ = searchPage(t, base, links["prev"])
+	assert.Equal(t, []int{1, 5}, ids)
+
+	_, ids, _ = searchPage(t, base, "/pages-test?sort=name,-id")
+	assert.Equal(t, []int{3, 4, 2}, ids)
+
+	// only sortable fields are accepted, and a cursor is only valid for its sort
+	for _, query := range []string{"sort=note", "sort=missing", "sort=name,-name", "sort=name&cursor=eyJrIjoxfQ"} {
+		response, _, _ := searchPage(t, base, "/pages-test?"+query)
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/franciscoescher/gosimplerest/logger"
 	"github.com/franciscoescher/gosimplerest/repository"
 	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/franciscoescher/gosimplerest/searchindex"
 	"github.com/franciscoescher/gosimplerest/validator"
 )
 
@@ -12,4 +13,30 @@ type GetHandlerFuncParams struct {
 	Validate   validator.Validator
 	Logger     logger.Logger
 	Repository repository.RepositoryInterface
+	// SearchIndex matches the q param of the search route, if set, and is kept
+	// in sync by the routes that write rows
+	SearchIndex searchindex.SearchIndex
+	// Includes are the relations whose rows can be embedded in the rows of the retrieve
+	// and search routes with the include param, by name
+	Includes map[string]Include
+}
+
+// Include is a relation of a resource, with its related resources
+type Include struct {
+	Relation resource.Relation
+	// Resource is the related resource
+	Resource *resource.Resource
+	// Through is the join resource of many to many relations
+	Through *resource.Resource
+}
+
+// RelationHandlerFuncParams are the params of the nested routes of a relation,
+// whose GetHandlerFuncParams are the ones of the related resource
+type RelationHandlerFuncParams struct {
+	GetHandlerFuncParams
+	// Parent is the resource with the relation, whose primary key is the id param of the routes
+	Parent   *resource.Resource
+	Relation resource.Relation
+	// Through is the join resource of many to many relations
+	Through *resource.Resource
 }
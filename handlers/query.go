@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// includeDeletedParam is the query param that includes the soft deleted rows in the results
+const includeDeletedParam = "include_deleted"
+
+// readIncludeDeleted reads the include_deleted query param, which is only accepted
+// if the resource allows it
+func readIncludeDeleted(r *http.Request, params *GetHandlerFuncParams) (bool, error) {
+	value := r.URL.Query().Get(includeDeletedParam)
+	if value == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", includeDeletedParam)
+	}
+	if include && !params.Resource.AllowIncludeDeleted {
+		return false, fmt.Errorf("%s is not allowed", includeDeletedParam)
+	}
+	return include, nil
+}
+
+// sortParam is the query param with the order of the search route
+const sortParam = "sort"
+
+// readSort reads the sort query param, a comma separated list of fields,
+// in descending order when prefixed with -. Only sortable fields are accepted,
+// so no other value reaches the repository.
+func readSort(r *http.Request, b *resource.Resource) ([]repository.Sort, error) {
+	value := r.URL.Query().Get(sortParam)
+	if value == "" {
+		return nil, nil
+	}
+	fields := strings.Split(value, ",")
+	sort := make([]repository.Sort, 0, len(fields))
+	sorted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		s := repository.Sort{Field: strings.TrimSpace(field)}
+		if strings.HasPrefix(s.Field, "-") {
+			s.Field, s.Desc = s.Field[1:], true
+		}
+		if !b.IsSortable(s.Field) {
+			return nil, fmt.Errorf("%s is not sortable", s.Field)
+		}
+		if sorted[s.Field] {
+			return nil, fmt.Errorf("%s is sorted more than once", s.Field)
+		}
+		sorted[s.Field] = true
+		sort = append(sort, s)
+	}
+	return sort, nil
+}
+
+// textParam is the query param with the full-text search of the search route
+const textParam = "q"
+
+// readText reads the q query param, which is only accepted if the resource has full-text fields
+func readText(r *http.Request, b *resource.Resource) (string, error) {
+	value := r.URL.Query().Get(textParam)
+	if value == "" {
+		return "", nil
+	}
+	if len(b.FullTextFields()) == 0 {
+		return "", fmt.Errorf("%s is not allowed", textParam)
+	}
+	if len(repository.TextTerms(value)) > repository.MaxTextTerms {
+		return "", fmt.Errorf("%s has more than %d words", textParam, repository.MaxTextTerms)
+	}
+	return value, nil
+}
+
+// fieldsParam is the query param with the fields returned by the retrieve and search routes
+const fieldsParam = "fields"
+
+// readFields reads the fields query param, a comma separated list of fields
+// of the resource. The primary key is always returned, even if not listed.
+func readFields(r *http.Request, b *resource.Resource) ([]string, error) {
+	value := r.URL.Query().Get(fieldsParam)
+	if value == "" {
+		return nil, nil
+	}
+	fields := strings.Split(value, ",")
+	for i, field := range fields {
+		fields[i] = strings.TrimSpace(field)
+		if !b.HasField(fields[i]) || b.IsHidden(fields[i]) {
+			return nil, fmt.Errorf("%s is not a field", fields[i])
+		}
+	}
+	return fields, nil
+}
+
+// omitFields removes from the rows the hidden fields of the resource, and the fields that were
+// read but not requested, such as the sorted fields, needed for the cursors
+func omitFields(rows []map[string]any, b *resource.Resource, fields []string) {
+	var requested map[string]bool
+	if len(fields) > 0 {
+		requested = map[string]bool{b.PrimaryKey: true}
+		for _, field := range fields {
+			requested[field] = true
+		}
+	}
+	for _, row := range rows {
+		for field := range row {
+			if b.IsHidden(field) || (requested != nil && !requested[field]) {
+				delete(row, field)
+			}
+		}
+	}
+}
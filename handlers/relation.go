@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// RelationSearchHandler returns a handler for the GET method of the nested route of a has many
+// or many to many relation, which searches the related rows of the parent row of the id param,
+// with the query params of the search route
+func RelationSearchHandler(params *RelationHandlerFuncParams) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := readParentID(w, r, params)
+		if !ok {
+			return
+		}
+		search(w, r, &params.GetHandlerFuncParams, func(ctx context.Context, tx repository.RepositoryInterface) (repository.Expr, error) {
+			_, err := findParent(ctx, tx, params, id)
+			if err != nil {
+				return nil, err
+			}
+			if params.Relation.Kind == resource.HasMany {
+				return repository.Filter{Field: params.Relation.ForeignKey, Op: resource.OpEq, Values: []any{id}}, nil
+			}
+			// the keys of the join rows are selected by the search, so their number is not limited
+			return repository.Related{
+				Field:    params.Resource.PrimaryKey,
+				Resource: params.Through,
+				Key:      params.Relation.OtherKey,
+				Where:    map[string][]any{params.Relation.ForeignKey: {id}},
+			}, nil
+		})
+	}
+}
+
+// RelationCreateHandler returns a handler for the POST method of the nested route of a has many
+// or many to many relation, which creates a row related to the parent row of the id param:
+// with its foreign key set to the id, or with a row of the join resource referencing both
+func RelationCreateHandler(params *RelationHandlerFuncParams) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := readParentID(w, r, params)
+		if !ok {
+			return
+		}
+		// the parent is checked in the transaction of the insert, so it is not deleted before it
+		check := func(ctx context.Context, tx repository.RepositoryInterface) error {
+			_, err := findParent(ctx, tx, params, id)
+			return err
+		}
+
+		if params.Relation.Kind == resource.HasMany {
+			create(w, r, &params.GetHandlerFuncParams, map[string]any{params.Relation.ForeignKey: id}, check, nil)
+			return
+		}
+		create(w, r, &params.GetHandlerFuncParams, nil, check, func(ctx context.Context, tx repository.RepositoryInterface, row map[string]any) error {
+			join := map[string]any{
+				params.Relation.ForeignKey: id,
+				params.Relation.OtherKey:   row[params.Resource.PrimaryKey],
+			}
+			prepareInsert(params.Through, join)
+			// the join row is validated as the rows of the create route of the join resource
+			err := params.Through.ParseRow(join)
+			if err != nil {
+				return invalidRowError{msg: err.Error()}
+			}
+			errs := params.Through.ValidateAllFields(params.Validate, join)
+			if len(errs) > 0 {
+				return invalidRowError{msg: fmt.Sprintf("%s", errs)}
+			}
+			_, err = tx.Insert(ctx, params.Through, join)
+			return err
+		})
+	}
+}
+
+// RelationRetrieveHandler returns a handler for the GET method of the nested route of a belongs
+// to relation, which retrieves the row referenced by the foreign key of the row of the id param,
+// with the query params of the retrieve route
+func RelationRetrieveHandler(params *RelationHandlerFuncParams) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := readParentID(w, r, params)
+		if !ok {
+			return
+		}
+		ctx, cancel := params.Resource.QueryContext(r.Context())
+		defer cancel()
+
+		includeDeleted, err := readIncludeDeleted(r, &params.GetHandlerFuncParams)
+		var fields []string
+		if err == nil {
+			fields, err = readFields(r, params.Resource)
+		}
+		var include []string
+		if err == nil {
+			include, err = readInclude(r, &params.GetHandlerFuncParams)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			err = encodeJsonError(w, r, err.Error())
+			if err != nil {
+				params.Logger.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		var result map[string]any
+		err = repository.WithReadTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+			parent, err := findParent(ctx, tx, params, id)
+			if err != nil {
+				return err
+			}
+			key := parent[params.Relation.ForeignKey]
+			if key == nil {
+				return nil
+			}
+			result, err = tx.Find(ctx, params.Resource, key, repository.FindOptions{IncludeDeleted: includeDeleted, Fields: includeFields(&params.GetHandlerFuncParams, fields, include)})
+			if err != nil || len(result) == 0 {
+				return err
+			}
+			return embed(ctx, tx, &params.GetHandlerFuncParams, []map[string]any{result}, include)
+		})
+		if err != nil {
+			writeRepositoryError(w, r, &params.GetHandlerFuncParams, err)
+			return
+		}
+
+		if len(result) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			err = encodeJsonError(w, r, "not found")
+			if err != nil {
+				params.Logger.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		omitFields([]map[string]any{result}, params.Resource, shownFields(fields, include))
+		err = encodeJson(w, r, result)
+		if err != nil {
+			params.Logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// readParentID reads the id param, converted to the type of the primary key of the parent,
+// writing a bad request if it is not valid
+func readParentID(w http.ResponseWriter, r *http.Request, params *RelationHandlerFuncParams) (any, bool) {
+	id, err := params.Parent.ParseValue(params.Parent.PrimaryKey, ReadParams(r, "id"))
+	if err == nil {
+		err = params.Parent.ValidateField(params.Validate, params.Parent.PrimaryKey, id)
+	}
+	if err != nil {
+		params.Logger.Error(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	return id, true
+}
+
+// findParent returns the parent row of the id, or repository.ErrNotFound if it does not exist
+// or is soft deleted
+func findParent(ctx context.Context, tx repository.RepositoryInterface, params *RelationHandlerFuncParams, id any) (map[string]any, error) {
+	parent, err := tx.Find(ctx, params.Parent, id, repository.FindOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(parent) == 0 {
+		return nil, repository.NewError(repository.ErrNotFound, fmt.Errorf("%s %v not found", params.Parent.Name, id))
+	}
+	return parent, nil
+}
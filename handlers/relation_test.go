@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ownerResource = resource.Resource{
+		Name:       "owners",
+		PrimaryKey: "id",
+		Fields:     map[string]resource.Field{"id": {}, "name": {}},
+	}
+	eventResource = resource.Resource{
+		Name:       "rent_events",
+		PrimaryKey: "id",
+		Fields:     map[string]resource.Field{"id": {}, "owner_id": {}, "hours": {Type: resource.TypeInt}},
+	}
+	vehicleResource = resource.Resource{
+		Name:       "vehicles",
+		PrimaryKey: "id",
+		Fields:     map[string]resource.Field{"id": {}, "plate": {}},
+	}
+	ownerVehicleResource = resource.Resource{
+		Name:       "owner_vehicles",
+		PrimaryKey: "id",
+		Fields:     map[string]resource.Field{"id": {}, "owner_id": {}, "vehicle_id": {}},
+	}
+)
+
+// relationParams returns the params of the nested routes of a relation of owners
+func relationParams(repo repository.RepositoryInterface, related *resource.Resource, rel resource.Relation) *RelationHandlerFuncParams {
+	p := &RelationHandlerFuncParams{
+		GetHandlerFuncParams: GetHandlerFuncParams{Resource: related, Logger: logrus.New(), Repository: repo, Validate: validator.New()},
+		Parent:               &ownerResource,
+		Relation:             rel,
+	}
+	if rel.Kind == resource.ManyToMany {
+		p.Through = &ownerVehicleResource
+	}
+	return p
+}
+
+// serveRelation serves a request to a nested route of the owner of the id
+func serveRelation(t *testing.T, h http.HandlerFunc, method, id, query string, body any) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	request, err := http.NewRequest(method, "/owners/"+id+"/rel?"+query, bytes.NewReader(data))
+	require.NoError(t, err)
+	request = GetRequestWithParams(request, map[string]string{"id": id})
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	return response
+}
+
+func TestRelationHasMany(t *testing.T) {
+	// Prepare the test
+	repo := local.NewRepository()
+	ctx := context.Background()
+	for _, o := range []string{"a", "b"} {
+		_, err := repo.Insert(ctx, &ownerResource, map[string]any{"id": o, "name": o})
+		require.NoError(t, err)
+	}
+	for i, owner := range []string{"a", "a", "b"} {
+		_, err := repo.Insert(ctx, &eventResource, map[string]any{"id": string(rune('1' + i)), "owner_id": owner, "hours": int64(i + 1)})
+		require.NoError(t, err)
+	}
+	params := relationParams(repo, &eventResource, resource.Relation{Kind: resource.HasMany, Resource: "rent_events", ForeignKey: "owner_id"})
+	list := func(id, query string) (int, []string) {
+		response := serveRelation(t, RelationSearchHandler(params), http.MethodGet, id, query, nil)
+		ids := make([]string, 0)
+		if response.Code == http.StatusOK {
+			var rows []map[string]any
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+			for _, row := range rows {
+				ids = append(ids, row["id"].(string))
+			}
+		}
+		return response.Code, ids
+	}
+
+	// Make assertions
+	code, ids := list("a", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"1", "2"}, ids)
+	_, ids = list("a", "hours=2")
+	assert.Equal(t, []string{"2"}, ids)
+	// the foreign key of the query params does not widen the scope
+	code, _ = list("a", "owner_id=b")
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = list("missing", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// the foreign key of created rows is the id of the route
+	response := serveRelation(t, RelationCreateHandler(params), http.MethodPost, "b", "", map[string]any{"owner_id": "a", "hours": 4})
+	require.Equal(t, http.StatusOK, response.Code)
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &created))
+	row, err := repo.Find(ctx, &eventResource, created["id"], repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "b", row["owner_id"])
+	response = serveRelation(t, RelationCreateHandler(params), http.MethodPost, "missing", "", map[string]any{"hours": 4})
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestRelationManyToMany(t *testing.T) {
+	// Prepare the test
+	repo := local.NewRepository()
+	ctx := context.Background()
+	for _, o := range []string{"a", "b"} {
+		_, err := repo.Insert(ctx, &ownerResource, map[string]any{"id": o, "name": o})
+		require.NoError(t, err)
+	}
+	for _, v := range []string{"x", "y"} {
+		_, err := repo.Insert(ctx, &vehicleResource, map[string]any{"id": v, "plate": v})
+		require.NoError(t, err)
+	}
+	_, err := repo.Insert(ctx, &ownerVehicleResource, map[string]any{"id": "1", "owner_id": "a", "vehicle_id": "y"})
+	require.NoError(t, err)
+	params := relationParams(repo, &vehicleResource, resource.Relation{
+		Kind: resource.ManyToMany, Resource: "vehicles", ForeignKey: "owner_id", Through: "owner_vehicles", OtherKey: "vehicle_id",
+	})
+	list := func(id string) []string {
+		response := serveRelation(t, RelationSearchHandler(params), http.MethodGet, id, "", nil)
+		plates := make([]string, 0)
+		if response.Code == http.StatusOK {
+			var rows []map[string]any
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+			for _, row := range rows {
+				plates = append(plates, row["plate"].(string))
+			}
+		}
+		return plates
+	}
+
+	// Make assertions
+	assert.Equal(t, []string{"y"}, list("a"))
+	assert.Equal(t, []string{}, list("b"))
+
+	// created rows are related by a row of the join resource
+	response := serveRelation(t, RelationCreateHandler(params), http.MethodPost, "b", "", map[string]any{"plate": "z"})
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, []string{"z"}, list("b"))
+	joins, err := repo.Search(ctx, &ownerVehicleResource, repository.Query{Where: map[string][]any{"owner_id": {"b"}}})
+	require.NoError(t, err)
+	assert.Len(t, joins, 1)
+
+	// the join rows are validated, and the created row is not kept without its join row
+	through := ownerVehicleResource
+	through.Fields = map[string]resource.Field{"id": {}, "owner_id": {Validator: "len=2"}, "vehicle_id": {}}
+	params.Through = &through
+	response = serveRelation(t, RelationCreateHandler(params), http.MethodPost, "b", "", map[string]any{"plate": "w"})
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, []string{"z"}, list("b"))
+}
+
+func TestRelationBelongsTo(t *testing.T) {
+	// Prepare the test
+	repo := local.NewRepository()
+	ctx := context.Background()
+	_, err := repo.Insert(ctx, &ownerResource, map[string]any{"id": "a", "name": "Ana"})
+	require.NoError(t, err)
+	for id, owner := range map[string]any{"1": "a", "2": nil} {
+		_, err := repo.Insert(ctx, &eventResource, map[string]any{"id": id, "owner_id": owner, "hours": int64(1)})
+		require.NoError(t, err)
+	}
+	// the parent of the route is the resource with the belongs to relation
+	params := &RelationHandlerFuncParams{
+		GetHandlerFuncParams: GetHandlerFuncParams{Resource: &ownerResource, Logger: logrus.New(), Repository: repo, Validate: validator.New()},
+		Parent:               &eventResource,
+		Relation:             resource.Relation{Kind: resource.BelongsTo, Resource: "owners", ForeignKey: "owner_id"},
+	}
+
+	// Make assertions
+	response := serveRelation(t, RelationRetrieveHandler(params), http.MethodGet, "1", "fields=name", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"id": "a", "name": "Ana"}`, response.Body.String())
+	response = serveRelation(t, RelationRetrieveHandler(params), http.MethodGet, "2", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	response = serveRelation(t, RelationRetrieveHandler(params), http.MethodGet, "3", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+// txOnlyRepository fails the test if rows are read outside of its transactions
+type txOnlyRepository struct {
+	*local.Repository
+	t *testing.T
+}
+
+func (r txOnlyRepository) Find(ctx context.Context, b *resource.Resource, id any, opts repository.FindOptions) (map[string]any, error) {
+	r.t.Errorf("%s %v read outside of a transaction", b.Name, id)
+	return r.Repository.Find(ctx, b, id, opts)
+}
+
+func TestRelationCreateChecksParentInTx(t *testing.T) {
+	// Prepare the test
+	repo := local.NewRepository()
+	_, err := repo.Insert(context.Background(), &ownerResource, map[string]any{"id": "a", "name": "a"})
+	require.NoError(t, err)
+	params := relationParams(txOnlyRepository{repo, t}, &eventResource, resource.Relation{Kind: resource.HasMany, Resource: "rent_events", ForeignKey: "owner_id"})
+
+	// Make assertions
+	response := serveRelation(t, RelationCreateHandler(params), http.MethodPost, "a", "", map[string]any{"hours": 1})
+	assert.Equal(t, http.StatusOK, response.Code)
+	response = serveRelation(t, RelationCreateHandler(params), http.MethodPost, "missing", "", map[string]any{"hours": 1})
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// RestoreHandler returns a handler for the POST method that restores a soft deleted row
+func RestoreHandler(params *GetHandlerFuncParams) http.HandlerFunc {
+	return softDeletedHandler(params, repository.RepositoryInterface.Restore)
+}
+
+// PurgeHandler returns a handler for the DELETE method that deletes a soft deleted row for good
+func PurgeHandler(params *GetHandlerFuncParams) http.HandlerFunc {
+	return softDeletedHandler(params, repository.RepositoryInterface.Purge)
+}
+
+// softDeletedHandler returns a handler that runs an operation of the repository on a soft deleted row
+func softDeletedHandler(params *GetHandlerFuncParams, op func(repository.RepositoryInterface, context.Context, *resource.Resource, any) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := params.Resource.QueryContext(r.Context())
+		defer cancel()
+
+		// validates id, converted to the type of the primary key
+		id, err := params.Resource.ParseValue(params.Resource.PrimaryKey, ReadParams(r, "id"))
+		if err == nil {
+			err = params.Resource.ValidateField(params.Validate, params.Resource.PrimaryKey, id)
+		}
+		if err != nil {
+			params.Logger.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = repository.WithTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+			return op(tx, ctx, params.Resource, id)
+		})
+		if err != nil {
+			writeRepositoryError(w, r, params, err)
+			return
+		}
+		syncIndex(ctx, params, id)
+	}
+}
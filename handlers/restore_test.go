@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stoewer/go-strcase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreHandler(t *testing.T) {
+	// Prepare the test
+	base := &GetHandlerFuncParams{Resource: &testResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+
+	var data = map[string]interface{}{
+		"uuid":       "0b6b2ef5-3c4a-4f0e-9d8e-2a7f7c1b5e11",
+		"first_name": "Fulano",
+		"deleted_at": time.Now().UTC(),
+	}
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
+
+	// Make the request
+	route := "/" + strcase.KebabCase(testResource.Table())
+	request, err := http.NewRequest(http.MethodPost, route, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request = GetRequestWithParams(request, map[string]string{"id": data["uuid"].(string)})
+	response := httptest.NewRecorder()
+	handler := http.HandlerFunc(RestoreHandler(base))
+	handler.ServeHTTP(response, request)
+
+	// Make assertions
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	dataDB, _ := base.Repository.Find(context.Background(), &testResource, data["uuid"], repository.FindOptions{})
+	assert.Equal(t, "Fulano", dataDB["first_name"])
+	assert.Nil(t, dataDB["deleted_at"])
+
+	// the row is not deleted anymore, so it can not be restored again
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestPurgeHandler(t *testing.T) {
+	// Prepare the test
+	base := &GetHandlerFuncParams{Resource: &testResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+
+	var data = map[string]interface{}{
+		"uuid":       "5d1f0c8e-7a2b-4c3d-8e9f-1a2b3c4d5e6f",
+		"first_name": "Fulano",
+		"deleted_at": nil,
+	}
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
+
+	// Make the request
+	route := "/" + strcase.KebabCase(testResource.Table())
+	request, err := http.NewRequest(http.MethodDelete, route, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request = GetRequestWithParams(request, map[string]string{"id": data["uuid"].(string)})
+	handler := http.HandlerFunc(PurgeHandler(base))
+
+	// only soft deleted rows can be purged
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	_ = base.Repository.Delete(context.Background(), &testResource, data["uuid"])
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	dataDB, _ := base.Repository.Find(context.Background(), &testResource, data["uuid"], repository.FindOptions{IncludeDeleted: true})
+	assert.Len(t, dataDB, 0)
+}
+
+func TestRetrieveHandlerIncludeDeleted(t *testing.T) {
+	// Prepare the test
+	allowed := testResource
+	allowed.AllowIncludeDeleted = true
+	base := &GetHandlerFuncParams{Resource: &testResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+
+	var data = map[string]interface{}{
+		"uuid":       "9c8b7a6d-5e4f-4a3b-9c2d-1e0f9a8b7c6d",
+		"first_name": "Fulano",
+		"deleted_at": time.Now().UTC(),
+	}
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
+
+	get := func(query string) int {
+		route := "/" + strcase.KebabCase(testResource.Table()) + query
+		request, err := http.NewRequest(http.MethodGet, route, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request = GetRequestWithParams(request, map[string]string{"id": data["uuid"].(string)})
+		response := httptest.NewRecorder()
+		http.HandlerFunc(RetrieveHandler(base)).ServeHTTP(response, request)
+		return response.Code
+	}
+
+	// Make assertions
+	assert.Equal(t, http.StatusNotFound, get(""))
+	assert.Equal(t, http.StatusBadRequest, get("?include_deleted=true"))
+
+	base.Resource = &allowed
+	assert.Equal(t, http.StatusOK, get("?include_deleted=true"))
+	assert.Equal(t, http.StatusNotFound, get("?include_deleted=false"))
+	assert.Equal(t, http.StatusBadRequest, get("?include_deleted=maybe"))
+}
@@ -2,25 +2,56 @@ package handlers
 
 import (
 	"net/http"
+
+	"github.com/franciscoescher/gosimplerest/repository"
 )
 
 // RetrieveHandler returns a handler for the GET method
 func RetrieveHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := ReadParams(r, "id")
+		ctx, cancel := params.Resource.QueryContext(r.Context())
+		defer cancel()
 
-		// validates id
-		err := params.Resource.ValidateField(params.Validate, params.Resource.PrimaryKey, id)
+		// validates id, converted to the type of the primary key
+		id, err := params.Resource.ParseValue(params.Resource.PrimaryKey, ReadParams(r, "id"))
+		if err == nil {
+			err = params.Resource.ValidateField(params.Validate, params.Resource.PrimaryKey, id)
+		}
 		if err != nil {
 			params.Logger.Error(err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		result, err := params.Repository.Find(params.Resource, id)
+		includeDeleted, err := readIncludeDeleted(r, params)
+		var fields []string
+		if err == nil {
+			fields, err = readFields(r, params.Resource)
+		}
+		var include []string
+		if err == nil {
+			include, err = readInclude(r, params)
+		}
 		if err != nil {
-			params.Logger.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusBadRequest)
+			err = encodeJsonError(w, r, err.Error())
+			if err != nil {
+				params.Logger.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		var result map[string]any
+		err = repository.WithReadTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+			result, err = tx.Find(ctx, params.Resource, id, repository.FindOptions{IncludeDeleted: includeDeleted, Fields: includeFields(params, fields, include)})
+			if err != nil || len(result) == 0 {
+				return err
+			}
+			return embed(ctx, tx, params, []map[string]any{result}, include)
+		})
+		if err != nil {
+			writeRepositoryError(w, r, params, err)
 			return
 		}
 
@@ -35,6 +66,7 @@ func RetrieveHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 			return
 		}
 
+		omitFields([]map[string]any{result}, params.Resource, shownFields(fields, include))
 		err = encodeJson(w, r, result)
 		if err != nil {
 			params.Logger.Error(err)
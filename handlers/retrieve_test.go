@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -25,10 +26,10 @@ func TestRetrieveHandler(t *testing.T) {
 		"uuid":       "644159a8-0b21-4250-8184-9f06457435c8",
 		"first_name": "Fulano",
 		"phone":      "+55 (11) 99999-9999",
-		"deleted_at": t1,
+		"deleted_at": nil,
 		"created_at": t1.Add(-time.Hour * 24),
 	}
-	_, _ = base.Repository.Insert(&testResource, data)
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
 
 	// Make the request
 	route := "/" + strcase.KebabCase(testResource.Table())
@@ -101,3 +102,36 @@ func TestRetrieveHandlerNotFound(t *testing.T) {
 	// Make assertions
 	assert.Equal(t, http.StatusNotFound, response.Code)
 }
+
+func TestRetrieveHandlerFields(t *testing.T) {
+	// Prepare the test
+	base := &GetHandlerFuncParams{Resource: &testResource, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+
+	var data = map[string]interface{}{
+		"uuid":       "3f1c2b4a-6d5e-4f7a-8b9c-0d1e2f3a4b5c",
+		"first_name": "Fulano",
+		"phone":      "+55 (11) 99999-9999",
+		"deleted_at": nil,
+	}
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
+
+	get := func(fields string) *httptest.ResponseRecorder {
+		route := "/" + strcase.KebabCase(testResource.Table()) + "?fields=" + fields
+		request, err := http.NewRequest(http.MethodGet, route, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request = GetRequestWithParams(request, map[string]string{"id": data["uuid"].(string)})
+		response := httptest.NewRecorder()
+		http.HandlerFunc(RetrieveHandler(base)).ServeHTTP(response, request)
+		return response
+	}
+
+	// Make assertions, the primary key is always returned
+	response := get("first_name")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `{"first_name":"Fulano","uuid":"3f1c2b4a-6d5e-4f7a-8b9c-0d1e2f3a4b5c"}`, strings.TrimSpace(response.Body.String()))
+
+	response = get("first_name,password")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -1,55 +1,167 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 
-	"github.com/sirupsen/logrus"
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
 )
 
+// searchParams are the query params of the search route that are not fields. Fields with
+// the same names are only filtered in the field[op]=value format, or in filter expressions.
+var searchParams = []string{includeDeletedParam, filterParam, textParam, fieldsParam, includeParam, sortParam, limitParam, offsetParam, cursorParam, countParam}
+
+// ShadowedFields returns the fields of the resource, in alphabetical order, whose names are
+// query params of the search or aggregate routes, so they can not be filtered by name alone
+func ShadowedFields(b *resource.Resource) []string {
+	reserved := make(map[string]bool, len(searchParams)+len(aggregateParams))
+	for _, param := range append(append([]string{}, searchParams...), aggregateParams...) {
+		reserved[param] = true
+	}
+	fields := make([]string, 0)
+	for _, field := range b.GetFieldNames() {
+		if reserved[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// scopeFunc returns the expression that restricts a search to the rows of a nested route,
+// read in the transaction of the search
+type scopeFunc func(ctx context.Context, tx repository.RepositoryInterface) (repository.Expr, error)
+
 // SearchHandler returns a handler for the GET method with query params
 func SearchHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		query := r.URL.Query()
-
-		// validates that all fields in data are in the model
-		for key := range query {
-			// validates fields
-			if !params.Resource.IsSearchable(key) {
-				w.WriteHeader(http.StatusBadRequest)
-				err := encodeJsonError(w, r, key+" is not searchable")
-				if err != nil {
-					params.Logger.Error(err)
-					w.WriteHeader(http.StatusInternalServerError)
-				}
-				return
-			}
-			// validates values
-			for _, v := range query[key] {
-				err := params.Resource.ValidateField(params.Validate, key, v)
-				if err != nil {
-					logrus.Error(err)
-					w.WriteHeader(http.StatusBadRequest)
-					return
-				}
-			}
-		}
+		search(w, r, params, nil)
+	}
+}
+
+// search writes the rows matching the query params, restricted by the expression of scope,
+// if not nil. Scoped searches are never matched by the search index.
+func search(w http.ResponseWriter, r *http.Request, params *GetHandlerFuncParams, scope scopeFunc) {
+	ctx, cancel := params.Resource.QueryContext(r.Context())
+	defer cancel()
 
-		result, err := params.Repository.Search(params.Resource, query)
+	includeDeleted, err := readIncludeDeleted(r, params)
+	var fields []string
+	if err == nil {
+		fields, err = readFields(r, params.Resource)
+	}
+	var include []string
+	if err == nil {
+		include, err = readInclude(r, params)
+	}
+	var expr repository.Expr
+	if err == nil {
+		expr, err = readFilterExpr(r, params)
+	}
+	var text string
+	if err == nil {
+		text, err = readText(r, params.Resource)
+	}
+	var sort []repository.Sort
+	if err == nil {
+		sort, err = readSort(r, params.Resource)
+	}
+	var pg page
+	if err == nil {
+		pg, err = readPage(r, params.Resource, sort)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		err = encodeJsonError(w, r, err.Error())
 		if err != nil {
 			params.Logger.Error(err)
 			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if len(result) == 0 {
-			w.WriteHeader(http.StatusNoContent)
-			return
 		}
+		return
+	}
+	if scope == nil && usesIndex(params, text) {
+		indexSearch(ctx, w, r, params, text, fields, include, pg)
+		return
+	}
 
-		err = encodeJson(w, r, result)
+	where, filters, err := readWhere(r, params, searchParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		err = encodeJsonError(w, r, err.Error())
 		if err != nil {
 			params.Logger.Error(err)
 			w.WriteHeader(http.StatusInternalServerError)
-			return
 		}
+		return
+	}
+	query := repository.Query{Where: where, Filters: filters, Expr: expr, Text: text, IncludeDeleted: includeDeleted, Sort: sort}
+	if len(fields) > 0 {
+		// the sorted fields are also read, for the cursors of the links
+		query.Fields = includeFields(params, fields, include)
+		for _, s := range sort {
+			query.Fields = append(query.Fields, s.Field)
+		}
+	}
+
+	// the count is of all the rows matching the search, so it is read before the pagination is set,
+	// and the related rows are embedded in the same transaction, so they are read from the same snapshot
+	var result []map[string]any
+	var more bool
+	var total int64
+	err = repository.WithReadTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+		var err error
+		if scope != nil {
+			e, err := scope(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if query.Expr != nil {
+				e = repository.And{query.Expr, e}
+			}
+			query.Expr = e
+		}
+		if pg.count {
+			total, err = tx.Count(ctx, params.Resource, query)
+			if err != nil {
+				return err
+			}
+		}
+		pg.apply(&query)
+		result, err = tx.Search(ctx, params.Resource, query)
+		if err != nil {
+			return err
+		}
+		result, more = pg.trim(result)
+		return embed(ctx, tx, params, result, include)
+	})
+	if err != nil {
+		writeRepositoryError(w, r, params, err)
+		return
+	}
+
+	links, err := pg.links(r, params.Resource, result, more)
+	if err != nil {
+		params.Logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if links != "" {
+		w.Header().Set("Link", links)
+	}
+	omitFields(result, params.Resource, shownFields(fields, include))
+	if pg.count {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	}
+	if len(result) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err = encodeJson(w, r, result)
+	if err != nil {
+		params.Logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
@@ -1,18 +1,24 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/franciscoescher/gosimplerest/repository"
 	"github.com/franciscoescher/gosimplerest/repository/local"
+	"github.com/franciscoescher/gosimplerest/resource"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 	"github.com/stoewer/go-strcase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSearchHandler(t *testing.T) {
@@ -25,14 +31,14 @@ func TestSearchHandler(t *testing.T) {
 		"uuid":       "4c017ccf-0749-4744-a5a6-9c92725411b9",
 		"first_name": "Fulano Search Test",
 		"phone":      "+55 (11) 99999-9999",
-		"deleted_at": t1,
+		"deleted_at": nil,
 		"created_at": t1.Add(-time.Hour * 24),
 	}
 	data2 := map[string]interface{}{
 		"uuid":       "6b548c12-5cac-42e9-aaf1-465c31fafd63",
 		"first_name": "Fulano Search Test",
 		"phone":      "+55 (11) 99999-9999",
-		"deleted_at": t1.Add(-time.Hour * 48),
+		"deleted_at": nil,
 		"created_at": t1.Add(-time.Hour * 72),
 	}
 	data3 := map[string]interface{}{
@@ -42,9 +48,9 @@ func TestSearchHandler(t *testing.T) {
 		"deleted_at": t1.Add(-time.Hour * 80),
 		"created_at": t1.Add(-time.Hour * 90),
 	}
-	_, _ = base.Repository.Insert(&testResource, data)
-	_, _ = base.Repository.Insert(&testResource, data2)
-	_, _ = base.Repository.Insert(&testResource, data3)
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data2)
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data3)
 
 	// Make the request
 	route := "/" + strcase.KebabCase(testResource.Table())
@@ -146,3 +152,104 @@ func TestSearchHandlerUnsearchable(t *testing.T) {
 	// Make assertions
 	assert.Equal(t, http.StatusBadRequest, response.Code)
 }
+
+func TestSearchHandlerIncludeDeleted(t *testing.T) {
+	// Prepare the test
+	allowed := testResource
+	allowed.AllowIncludeDeleted = true
+	base := &GetHandlerFuncParams{Resource: &allowed, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+
+	_, _ = base.Repository.Insert(context.Background(), &testResource, map[string]interface{}{
+		"uuid":       "1d2e3f4a-5b6c-4d7e-8f9a-0b1c2d3e4f5a",
+		"first_name": "Deleted Search Test",
+		"deleted_at": time.Now().UTC(),
+	})
+
+	search := func(query string) int {
+		route := "/" + strcase.KebabCase(testResource.Table()) + "?first_name=Deleted+Search+Test" + query
+		request, err := http.NewRequest(http.MethodGet, route, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+		http.HandlerFunc(SearchHandler(base)).ServeHTTP(response, request)
+		return response.Code
+	}
+
+	// Make assertions
+	assert.Equal(t, http.StatusNoContent, search(""))
+	assert.Equal(t, http.StatusOK, search("&include_deleted=true"))
+}
+
+func TestSearchHandlerFields(t *testing.T) {
+	base := newPageTest(t)
+
+	// the sorted field is read for the cursor, but not returned
+	request, err := http.NewRequest(http.MethodGet, "/pages-test?fields=id&sort=-name&limit=1", nil)
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	http.HandlerFunc(SearchHandler(base)).ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `[{"id":1}]`, strings.TrimSpace(response.Body.String()))
+	assert.Contains(t, response.Header().Get("Link"), `rel="next"`)
+
+	request, err = http.NewRequest(http.MethodGet, "/pages-test?fields=id,missing", nil)
+	require.NoError(t, err)
+	response = httptest.NewRecorder()
+	http.HandlerFunc(SearchHandler(base)).ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestSearchHandlerText(t *testing.T) {
+	// Prepare the test
+	users := testResource
+	users.Fields = map[string]resource.Field{}
+	for name, field := range testResource.Fields {
+		field.FullText = name == "first_name"
+		users.Fields[name] = field
+	}
+	users.Fields["last_name"] = resource.Field{FullText: true}
+	base := &GetHandlerFuncParams{Resource: &users, Logger: logrus.New(), Repository: local.NewRepository(), Validate: validator.New()}
+	for i, name := range [][2]string{{"Ana", "Silva"}, {"Mariana", "Souza"}, {"Fulano", "Silva"}} {
+		_, err := base.Repository.Insert(context.Background(), &users, map[string]interface{}{
+			"uuid":       fmt.Sprintf("1d2e3f4a-5b6c-4d7e-8f9a-0b1c2d3e4f5%d", i),
+			"first_name": name[0],
+			"last_name":  name[1],
+		})
+		require.NoError(t, err)
+	}
+
+	search := func(params *GetHandlerFuncParams, q string) (int, []string) {
+		request, err := http.NewRequest(http.MethodGet, "/users?fields=first_name&q="+url.QueryEscape(q), nil)
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		http.HandlerFunc(SearchHandler(params)).ServeHTTP(response, request)
+		names := make([]string, 0)
+		if response.Code == http.StatusOK {
+			var rows []map[string]any
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+			for _, row := range rows {
+				names = append(names, row["first_name"].(string))
+			}
+		}
+		return response.Code, names
+	}
+
+	// Make assertions
+	code, names := search(base, "silva")
+	assert.Equal(t, http.StatusOK, code)
+	assert.ElementsMatch(t, []string{"Ana", "Fulano"}, names)
+	_, names = search(base, "ana SIL")
+	assert.Equal(t, []string{"Ana"}, names)
+
+	words := make([]string, repository.MaxTextTerms+1)
+	for i := range words {
+		words[i] = fmt.Sprint("word", i)
+	}
+	code, _ = search(base, strings.Join(words, " "))
+	assert.Equal(t, http.StatusBadRequest, code)
+	noText := *base
+	noText.Resource = &testResource
+	code, _ = search(&noText, "silva")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
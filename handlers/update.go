@@ -5,11 +5,16 @@ import (
 	"net/http"
 
 	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
 )
 
 // UpdateHandler returns a handler for the PATCH method
 func UpdateHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := params.Resource.QueryContext(r.Context())
+		defer cancel()
+
 		data, err := unmarshalBody(r)
 		if err != nil {
 			params.Logger.Error(err)
@@ -65,6 +70,17 @@ func UpdateHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 				return
 			}
 		}
+		// converts values to the types of the fields
+		err = params.Resource.ParseRow(data)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			err = encodeJsonError(w, r, err.Error())
+			if err != nil {
+				params.Logger.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
 		// validates values
 		errs := params.Resource.ValidateInputFields(params.Validate, data)
 		if len(errs) > 0 {
@@ -77,15 +93,19 @@ func UpdateHandler(params *GetHandlerFuncParams) http.HandlerFunc {
 			return
 		}
 
-		affected, err := params.Repository.Update(params.Resource, data)
+		var affected bool
+		err = repository.WithTx(ctx, params.Repository, func(tx repository.RepositoryInterface) error {
+			affected, err = tx.Update(ctx, params.Resource, data)
+			return err
+		})
 		if err != nil {
-			params.Logger.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, r, params, err)
 			return
 		}
 		if !affected {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		syncIndex(ctx, params, data[params.Resource.PrimaryKey])
 	}
 }
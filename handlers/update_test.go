@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/franciscoescher/gosimplerest/repository"
 	"github.com/franciscoescher/gosimplerest/repository/local"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
@@ -25,10 +27,10 @@ func TestUpdateHandlerPatchOK(t *testing.T) {
 		"uuid":       "683143f8-e262-409c-b0a7-3df3ef296e2b",
 		"first_name": "Fulano",
 		"phone":      "+55 (11) 99999-9999",
-		"deleted_at": t1,
+		"deleted_at": nil,
 		"created_at": t1.Add(-time.Hour * 24),
 	}
-	_, _ = base.Repository.Insert(&testResource, data)
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
 
 	// Prepare the request
 	dataUpdate := map[string]interface{}{
@@ -56,7 +58,7 @@ func TestUpdateHandlerPatchOK(t *testing.T) {
 
 	data["first_name"] = dataUpdate["first_name"]
 	data["phone"] = dataUpdate["phone"]
-	dataDB, _ := base.Repository.Find(&testResource, data["uuid"])
+	dataDB, _ := base.Repository.Find(context.Background(), &testResource, data["uuid"], repository.FindOptions{})
 
 	assert.Equal(t, data, dataDB)
 }
@@ -71,10 +73,10 @@ func TestUpdateHandlerPutOK(t *testing.T) {
 		"uuid":       "bec64968-663e-4e9e-9598-f3c139106bc4",
 		"first_name": "Fulano",
 		"phone":      "+55 (11) 99999-9999",
-		"deleted_at": t1,
+		"deleted_at": nil,
 		"created_at": t1.Add(-time.Hour * 24),
 	}
-	_, _ = base.Repository.Insert(&testResource, data)
+	_, _ = base.Repository.Insert(context.Background(), &testResource, data)
 
 	// Prepare the request
 	dataUpdate := map[string]interface{}{
@@ -101,7 +103,7 @@ func TestUpdateHandlerPutOK(t *testing.T) {
 
 	data["first_name"] = dataUpdate["first_name"]
 	data["phone"] = dataUpdate["phone"]
-	dataDB, _ := base.Repository.Find(&testResource, data["uuid"])
+	dataDB, _ := base.Repository.Find(context.Background(), &testResource, data["uuid"], repository.FindOptions{})
 
 	assert.Equal(t, dataUpdate["first_name"], dataDB["first_name"])
 	assert.Equal(t, dataUpdate["phone"], dataDB["phone"])
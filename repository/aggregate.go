@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Aggregator is implemented by the repositories that can group the rows of a resource
+// and aggregate their values, such as counting the rows or summing a field
+type Aggregator interface {
+	// Aggregate returns a row for each group of the rows matching the query, with the values
+	// of the group fields and of the aggregates, under their Name. Groups are ordered by the
+	// group fields, with null values first. Without group fields, a single row is returned,
+	// even if no row matches the query.
+	Aggregate(ctx context.Context, b *resource.Resource, q AggregateQuery) ([]map[string]any, error)
+}
+
+// AggregateFunc is a function that aggregates the values of a field over the rows of a group
+type AggregateFunc string
+
+const (
+	// AggCount counts the rows, with the field *, or the values of a field that are not null
+	AggCount AggregateFunc = "count"
+	// AggSum sums the values of a numeric field, null if there are none
+	AggSum AggregateFunc = "sum"
+	// AggAvg averages the values of a numeric field, null if there are none
+	AggAvg AggregateFunc = "avg"
+	// AggMin returns the lowest value of a field, null if there are none
+	AggMin AggregateFunc = "min"
+	// AggMax returns the highest value of a field, null if there are none
+	AggMax AggregateFunc = "max"
+)
+
+// AllRows is the field of the count of all rows
+const AllRows = "*"
+
+// Aggregate is a function applied to a field
+type Aggregate struct {
+	Func  AggregateFunc
+	Field string
+}
+
+// AggregateQuery groups the rows matching a query
+type AggregateQuery struct {
+	// Query selects the rows, with its where, filters, expression, full-text search
+	// and soft deletes. Its fields, sort and pagination are ignored.
+	Query
+	// GroupBy are the fields the rows are grouped by
+	GroupBy []string
+	// Aggregates are computed for each group
+	Aggregates []Aggregate
+}
+
+// Name returns the key of the aggregate in the rows returned: count for the
+// count of rows, and the function and the field otherwise, such as sum_hours
+func (a Aggregate) Name() string {
+	if a.Field == AllRows {
+		return string(a.Func)
+	}
+	return string(a.Func) + "_" + a.Field
+}
+
+// Type returns the type of the values of the aggregate: counts are ints, averages are
+// decimals for decimal fields and floats otherwise, and the others have the type of the field
+func (a Aggregate) Type(b *resource.Resource) resource.Type {
+	switch a.Func {
+	case AggCount:
+		return resource.TypeInt
+	case AggAvg:
+		if b.Fields[a.Field].Type == resource.TypeDecimal {
+			return resource.TypeDecimal
+		}
+		return resource.TypeFloat
+	}
+	return b.Fields[a.Field].Type
+}
+
+// numeric returns true if the values of the type can be summed and averaged
+func numeric(t resource.Type) bool {
+	switch t {
+	case resource.TypeInt, resource.TypeFloat, resource.TypeDecimal, resource.TypeAny:
+		return true
+	}
+	return false
+}
+
+// ValidateAggregate returns an error if a group field or an aggregated field is not
+// aggregatable, or an aggregate function does not apply to its field, besides
+// the errors of the filters, expression and full-text search of the query
+func ValidateAggregate(b *resource.Resource, q AggregateQuery) error {
+	if len(q.Aggregates) == 0 {
+		return NewError(ErrInvalidQuery, errors.New("aggregate without functions"))
+	}
+	names := make(map[string]bool, len(q.GroupBy)+len(q.Aggregates))
+	for _, field := range q.GroupBy {
+		if !b.IsAggregatable(field) {
+			return NewError(ErrInvalidQuery, fmt.Errorf("field %s is not aggregatable", field))
+		}
+		if names[field] {
+			return NewError(ErrInvalidQuery, fmt.Errorf("field %s is grouped more than once", field))
+		}
+		names[field] = true
+	}
+	for _, a := range q.Aggregates {
+		switch {
+		case a.Field == AllRows:
+			if a.Func != AggCount {
+				return NewError(ErrInvalidQuery, fmt.Errorf("%s of all rows", a.Func))
+			}
+		case !b.IsAggregatable(a.Field):
+			return NewError(ErrInvalidQuery, fmt.Errorf("field %s is not aggregatable", a.Field))
+		}
+		t := b.Fields[a.Field].Type
+		switch a.Func {
+		case AggCount:
+		case AggSum, AggAvg:
+			if !numeric(t) {
+				return NewError(ErrInvalidQuery, fmt.Errorf("%s of field %s, which is not numeric", a.Func, a.Field))
+			}
+		case AggMin, AggMax:
+			if !resource.OpGt.AppliesTo(t) {
+				return NewError(ErrInvalidQuery, fmt.Errorf("%s of field %s, which is not ordered", a.Func, a.Field))
+			}
+		default:
+			return NewError(ErrInvalidQuery, fmt.Errorf("invalid aggregate function %s", a.Func))
+		}
+		if names[a.Name()] {
+			return NewError(ErrInvalidQuery, fmt.Errorf("%s is returned more than once", a.Name()))
+		}
+		names[a.Name()] = true
+	}
+	err := ValidateFilters(b, q.Filters)
+	if err == nil {
+		err = ValidateExpr(b, q.Expr)
+	}
+	if err == nil {
+		err = ValidateText(b, q.Text)
+	}
+	return err
+}
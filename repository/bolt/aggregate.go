@@ -0,0 +1,24 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Compile-time check that Repository implements the Aggregator interface
+var _ repository.Aggregator = (*Repository)(nil)
+
+func (r Repository) Aggregate(ctx context.Context, b *resource.Resource, q repository.AggregateQuery) ([]map[string]any, error) {
+	err := repository.ValidateAggregate(b, q)
+	if err != nil {
+		return nil, err
+	}
+	results, err := r.matching(ctx, b, q.Query)
+	if err != nil {
+		return nil, err
+	}
+	return memquery.Aggregate(b, results, q)
+}
@@ -0,0 +1,20 @@
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.RunConformance(t, func(t *testing.T) repository.RepositoryInterface {
+		db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return NewRepository(db)
+	})
+}
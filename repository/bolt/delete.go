@@ -0,0 +1,73 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (r Repository) Delete(ctx context.Context, b *resource.Resource, id any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.update(func(tx *bolt.Tx) error {
+		bk, row, err := findRow(tx, b, id)
+		if err != nil {
+			return err
+		}
+		if row == nil || memquery.Deleted(b, row) {
+			return fmt.Errorf("%w: no rows affected", repository.ErrNotFound)
+		}
+
+		// soft deletes stamp the soft delete field instead of removing the row
+		if b.SoftDeleteField.Valid {
+			row[b.SoftDeleteField.String] = time.Now()
+			return putRow(bk, id, row)
+		}
+		return bk.Delete(key(id))
+	})
+}
+
+func (r Repository) Restore(ctx context.Context, b *resource.Resource, id any) error {
+	if !b.SoftDeleteField.Valid {
+		return fmt.Errorf("%w: resource has no soft delete field", repository.ErrInvalidQuery)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.update(func(tx *bolt.Tx) error {
+		bk, row, err := findRow(tx, b, id)
+		if err != nil {
+			return err
+		}
+		if !memquery.Deleted(b, row) {
+			return fmt.Errorf("%w: no rows affected", repository.ErrNotFound)
+		}
+		row[b.SoftDeleteField.String] = nil
+		return putRow(bk, id, row)
+	})
+}
+
+func (r Repository) Purge(ctx context.Context, b *resource.Resource, id any) error {
+	if !b.SoftDeleteField.Valid {
+		return fmt.Errorf("%w: resource has no soft delete field", repository.ErrInvalidQuery)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.update(func(tx *bolt.Tx) error {
+		bk, row, err := findRow(tx, b, id)
+		if err != nil {
+			return err
+		}
+		if !memquery.Deleted(b, row) {
+			return fmt.Errorf("%w: no rows affected", repository.ErrNotFound)
+		}
+		return bk.Delete(key(id))
+	})
+}
@@ -0,0 +1,30 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (r Repository) Find(ctx context.Context, b *resource.Resource, id any, opts repository.FindOptions) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	columns, err := repository.Columns(b, opts.Fields)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]any, 0)
+	err = r.view(func(tx *bolt.Tx) error {
+		_, row, err := findRow(tx, b, id)
+		if err != nil || row == nil || (!opts.IncludeDeleted && memquery.Deleted(b, row)) {
+			return err
+		}
+		result = memquery.Project([]map[string]any{row}, columns)[0]
+		return nil
+	})
+	return result, err
+}
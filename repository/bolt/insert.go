@@ -0,0 +1,55 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (r Repository) Insert(ctx context.Context, b *resource.Resource, data map[string]any) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := r.update(func(tx *bolt.Tx) error {
+		bk, err := tx.CreateBucketIfNotExists([]byte(b.Table()))
+		if err != nil {
+			return err
+		}
+
+		row := make(map[string]any, len(data))
+		for k, v := range data {
+			row[k] = v
+		}
+
+		var pk any
+		if b.AutoIncrementalPK {
+			seq, err := bk.NextSequence()
+			if err != nil {
+				return err
+			}
+			id = int64(seq)
+			pk = id
+			row[b.PrimaryKey] = pk
+		} else {
+			pk = data[b.PrimaryKey]
+		}
+
+		if pk == nil {
+			return fmt.Errorf("%w: primary key not found", repository.ErrInvalidQuery)
+		}
+
+		// checks if pk already exists
+		if bk.Get(key(pk)) != nil {
+			return fmt.Errorf("%w: primary key already exists", repository.ErrConflict)
+		}
+		return putRow(bk, pk, row)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
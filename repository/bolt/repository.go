@@ -0,0 +1,96 @@
+package bolt
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Repository is the implementation of the RepositoryInterface for bbolt, an embedded key-value store.
+// Each resource table is a bucket, where the key is the primary key and
+// the value is the row encoded as json.
+type Repository struct {
+	db *bolt.DB
+	// tx is the transaction the repository is bound to, nil outside transactions
+	tx *bolt.Tx
+}
+
+// NewRepository returns a new bbolt Repository
+func NewRepository(db *bolt.DB) Repository {
+	return Repository{db: db}
+}
+
+// Open opens the bbolt database file at path, creating it if it does not exist.
+// bbolt locks the file, so it fails after one second if another process has it open.
+func Open(path string) (*bolt.DB, error) {
+	return bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+}
+
+// Compile-time check that Repository implements the Repository and transaction interfaces
+var _ repository.RepositoryInterface = (*Repository)(nil)
+var _ repository.Transactional = (*Repository)(nil)
+var _ repository.ReadTransactional = (*Repository)(nil)
+
+// view runs fn in a read-only transaction, or in the transaction the repository is bound to
+func (r Repository) view(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.View(fn)
+}
+
+// update runs fn in a read-write transaction, or in the transaction the repository is bound to
+func (r Repository) update(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.Update(fn)
+}
+
+// key returns the key of a primary key value in the bucket
+func key(pk any) []byte {
+	return []byte(memquery.Key(pk))
+}
+
+// decodeRow decodes a row stored in a bucket, converting its values to the types of the fields
+func decodeRow(b *resource.Resource, data []byte) (map[string]any, error) {
+	row := make(map[string]any, 0)
+	err := memquery.DecodeJSON(data, &row)
+	if err != nil {
+		return nil, err
+	}
+	return b.ScanRow(row)
+}
+
+// findRow returns the bucket of the resource and the row with the given primary key,
+// or a nil row if it does not exist
+func findRow(tx *bolt.Tx, b *resource.Resource, id any) (*bolt.Bucket, map[string]any, error) {
+	bk := bucket(tx, b)
+	if bk == nil {
+		return nil, nil, nil
+	}
+	data := bk.Get(key(id))
+	if data == nil {
+		return bk, nil, nil
+	}
+	row, err := decodeRow(b, data)
+	return bk, row, err
+}
+
+// putRow encodes and stores a row in the bucket of the resource
+func putRow(bucket *bolt.Bucket, pk any, row map[string]any) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key(pk), data)
+}
+
+// bucket returns the bucket of the resource, nil if it was never written
+func bucket(tx *bolt.Tx, b *resource.Resource) *bolt.Bucket {
+	return tx.Bucket([]byte(b.Table()))
+}
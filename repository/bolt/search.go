@@ -0,0 +1,99 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (r Repository) Search(ctx context.Context, b *resource.Resource, q repository.Query) ([]map[string]any, error) {
+	columns, err := repository.Columns(b, q.Fields)
+	if err != nil {
+		return nil, err
+	}
+	results, err := r.matching(ctx, b, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// rows are ordered by the sort of the query and the primary key, as in the sql repositories
+	memquery.Sort(results, q.Order(b.PrimaryKey))
+	results, err = memquery.Paginate(b, results, q)
+	if err != nil {
+		return nil, repository.NewError(repository.ErrInvalidQuery, err)
+	}
+	return memquery.Project(results, columns), nil
+}
+
+func (r Repository) Count(ctx context.Context, b *resource.Resource, q repository.Query) (int64, error) {
+	results, err := r.matching(ctx, b, q)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(results)), nil
+}
+
+// matching returns the rows matching the where, filters, expression, full-text search and soft deletes of the query
+func (r Repository) matching(ctx context.Context, b *resource.Resource, q repository.Query) (results []map[string]any, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if r.tx == nil {
+		// the rows of the related expressions are read in the same transaction
+		err = r.db.View(func(tx *bolt.Tx) error {
+			results, err = Repository{db: r.db, tx: tx}.matching(ctx, b, q)
+			return err
+		})
+		return results, err
+	}
+	query, err := memquery.ScanQuery(b, q.Where)
+	if err != nil {
+		return nil, repository.NewError(repository.ErrInvalidQuery, err)
+	}
+	filters, err := memquery.ScanFilters(b, q.Filters)
+	if err != nil {
+		return nil, err
+	}
+	e, err := memquery.ResolveRelated(b, q.Expr, func(b *resource.Resource, q repository.Query) ([]map[string]any, error) {
+		return r.matching(ctx, b, q)
+	})
+	if err != nil {
+		return nil, err
+	}
+	expr, err := memquery.ScanExpr(b, e)
+	if err != nil {
+		return nil, err
+	}
+	err = repository.ValidateText(b, q.Text)
+	if err != nil {
+		return nil, err
+	}
+	terms := repository.TextTerms(q.Text)
+	results = make([]map[string]any, 0)
+	err = r.view(func(tx *bolt.Tx) error {
+		bk := bucket(tx, b)
+		if bk == nil {
+			return nil
+		}
+		return bk.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			row, err := decodeRow(b, v)
+			if err != nil {
+				return err
+			}
+			if (q.IncludeDeleted || !memquery.Deleted(b, row)) && memquery.Matches(row, query) && memquery.MatchesFilters(row, filters) && expr.Matches(row) && memquery.MatchesText(b, row, terms) {
+				results = append(results, row)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
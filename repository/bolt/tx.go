@@ -0,0 +1,38 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// WithTx runs fn in a read-write bbolt transaction, passing a repository bound to it.
+// bbolt allows a single writer, so transactions are serialized.
+// If the repository is already bound to a transaction, fn runs in it.
+func (r Repository) WithTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return fn(Repository{db: r.db, tx: tx})
+	})
+}
+
+// WithReadTx runs fn in a read-only bbolt transaction, passing a repository bound to it,
+// which runs along with the other read-only transactions and the writer.
+// If the repository is already bound to a transaction, fn runs in it.
+func (r Repository) WithReadTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.View(func(tx *bolt.Tx) error {
+		return fn(Repository{db: r.db, tx: tx})
+	})
+}
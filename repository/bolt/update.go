@@ -0,0 +1,39 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (r Repository) Update(ctx context.Context, b *resource.Resource, data map[string]any) (bool, error) {
+	// if the primary key is not in the data, return false
+	pk, ok := data[b.PrimaryKey]
+	if !ok {
+		return false, fmt.Errorf("%w: primary key not in data", repository.ErrInvalidQuery)
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	updated := false
+	err := r.update(func(tx *bolt.Tx) error {
+		bk, row, err := findRow(tx, b, pk)
+		if err != nil || row == nil || memquery.Deleted(b, row) {
+			return err
+		}
+		for k, v := range data {
+			row[k] = v
+		}
+		updated = true
+		return putRow(bk, pk, row)
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
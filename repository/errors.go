@@ -0,0 +1,44 @@
+package repository
+
+import "errors"
+
+// Errors returned by the repositories, so the handlers can tell the cause of a failure
+// without knowing the storage. Check them with errors.Is.
+var (
+	// ErrNotFound is returned when the row of an operation does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a row with the same primary key or unique value already exists
+	ErrConflict = errors.New("conflict")
+	// ErrConstraint is returned when the data violates a constraint of the storage,
+	// such as a foreign key, a not null column or the length of a column
+	ErrConstraint = errors.New("constraint violation")
+	// ErrInvalidQuery is returned when the storage can not run the operation as given,
+	// such as an unknown column or a missing primary key
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// Error is an error of the storage translated to one of the repository errors.
+// errors.Is matches both the repository error and the original one.
+type Error struct {
+	// Kind is one of the repository errors
+	Kind error
+	// Err is the error of the storage
+	Err error
+}
+
+// NewError returns an Error of the given kind, wrapping the error of the storage
+func NewError(kind error, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
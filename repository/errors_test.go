@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("duplicate entry")
+	err := fmt.Errorf("insert: %w", NewError(ErrConflict, cause))
+
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, cause)
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.Equal(t, "insert: conflict: duplicate entry", err.Error())
+}
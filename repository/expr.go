@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Expr is a node of a boolean filter expression: And, Or, Not, Related or a Filter.
+// Null values are unknown, as in sql, so a Not of a comparison with
+// a null value does not match either.
+type Expr interface {
+	expr()
+}
+
+// And matches the rows matching all of its expressions
+type And []Expr
+
+// Or matches the rows matching any of its expressions
+type Or []Expr
+
+// Not matches the rows not matching its expression
+type Not struct {
+	Expr Expr
+}
+
+// Related matches the rows whose field is one of the values of the Key field of the rows of
+// another resource matching Where, which are not soft deleted, such as the rows linked to a row
+// by the join resource of a many to many relation. The values are not read before the search,
+// so there is no limit to their number.
+type Related struct {
+	Field    string
+	Resource *resource.Resource
+	Key      string
+	// Where is a map of field names of the other resource and values, as Query.Where
+	Where map[string][]any
+}
+
+func (And) expr()     {}
+func (Or) expr()      {}
+func (Not) expr()     {}
+func (Related) expr() {}
+func (Filter) expr()  {}
+
+// ValidateExpr returns an error if a node of the expression is empty,
+// or if one of its filters is not valid, as ValidateFilters
+func ValidateExpr(b *resource.Resource, e Expr) error {
+	switch e := e.(type) {
+	case nil:
+		return nil
+	case And:
+		return validateExprs(b, e)
+	case Or:
+		return validateExprs(b, e)
+	case Not:
+		if e.Expr == nil {
+			return NewError(ErrInvalidQuery, errors.New("not without expression"))
+		}
+		return ValidateExpr(b, e.Expr)
+	case Related:
+		return validateRelated(b, e)
+	case Filter:
+		return ValidateFilters(b, []Filter{e})
+	}
+	return NewError(ErrInvalidQuery, errors.New("unknown expression"))
+}
+
+// validateExprs validates the expressions of an And or an Or
+func validateExprs(b *resource.Resource, exprs []Expr) error {
+	if len(exprs) == 0 {
+		return NewError(ErrInvalidQuery, errors.New("empty expression"))
+	}
+	for _, e := range exprs {
+		if e == nil {
+			return NewError(ErrInvalidQuery, errors.New("empty expression"))
+		}
+		err := ValidateExpr(b, e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRelated returns an error if the fields of a related expression are not fields of their resources
+func validateRelated(b *resource.Resource, e Related) error {
+	if e.Resource == nil {
+		return NewError(ErrInvalidQuery, errors.New("related expression without resource"))
+	}
+	if !b.HasField(e.Field) {
+		return NewError(ErrInvalidQuery, fmt.Errorf("field %s does not exist", e.Field))
+	}
+	if !e.Resource.HasField(e.Key) {
+		return NewError(ErrInvalidQuery, fmt.Errorf("field %s of %s does not exist", e.Key, e.Resource.Name))
+	}
+	for field := range e.Where {
+		if !e.Resource.HasField(field) {
+			return NewError(ErrInvalidQuery, fmt.Errorf("field %s of %s does not exist", field, e.Resource.Name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package local
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Compile-time check that Repository implements the Aggregator interface
+var _ repository.Aggregator = (*Repository)(nil)
+
+func (r *Repository) Aggregate(ctx context.Context, b *resource.Resource, q repository.AggregateQuery) ([]map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	err := repository.ValidateAggregate(b, q)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results, err := r.matching(b, q.Query)
+	if err != nil {
+		return nil, err
+	}
+	return memquery.Aggregate(b, results, q)
+}
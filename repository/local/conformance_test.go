@@ -0,0 +1,25 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.RunConformance(t, func(t *testing.T) repository.RepositoryInterface {
+		return NewRepository()
+	})
+}
+
+func TestConformancePersisted(t *testing.T) {
+	repositorytest.RunConformance(t, func(t *testing.T) repository.RepositoryInterface {
+		r, err := OpenRepository(PersistOptions{Dir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.Close() })
+		return r
+	})
+}
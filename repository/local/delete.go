@@ -1,15 +1,93 @@
 package local
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
 	"github.com/franciscoescher/gosimplerest/resource"
 )
 
-func (r Repository) Delete(b *resource.Resource, id any) error {
-	if _, ok := r.data[id]; !ok {
-		return fmt.Errorf("no rows affected")
+func (r *Repository) Delete(ctx context.Context, b *resource.Resource, id any) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	delete(r.data, id)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t := r.readTable(b.Table()); t == nil || t.rows[key(id)] == nil || memquery.Deleted(b, t.rows[key(id)]) {
+		return fmt.Errorf("%w: no rows affected", repository.ErrNotFound)
+	}
+	t := r.writeTable(b.Table())
+
+	// soft deletes stamp the soft delete field instead of removing the row
+	if b.SoftDeleteField.Valid {
+		deleted := copyRow(t.rows[key(id)])
+		deleted[b.SoftDeleteField.String] = time.Now()
+		err := r.record(record{Op: opUpdate, Table: b.Table(), Key: key(id), Row: deleted})
+		if err != nil {
+			return err
+		}
+		t.rows[key(id)] = deleted
+		return nil
+	}
+
+	err := r.record(record{Op: opDelete, Table: b.Table(), Key: key(id)})
+	if err != nil {
+		return err
+	}
+	delete(t.rows, key(id))
+	return nil
+}
+
+func (r *Repository) Restore(ctx context.Context, b *resource.Resource, id any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !b.SoftDeleteField.Valid {
+		return fmt.Errorf("%w: resource has no soft delete field", repository.ErrInvalidQuery)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t := r.readTable(b.Table()); t == nil || !memquery.Deleted(b, t.rows[key(id)]) {
+		return fmt.Errorf("%w: no rows affected", repository.ErrNotFound)
+	}
+	t := r.writeTable(b.Table())
+
+	restored := copyRow(t.rows[key(id)])
+	restored[b.SoftDeleteField.String] = nil
+	err := r.record(record{Op: opUpdate, Table: b.Table(), Key: key(id), Row: restored})
+	if err != nil {
+		return err
+	}
+	t.rows[key(id)] = restored
+	return nil
+}
+
+func (r *Repository) Purge(ctx context.Context, b *resource.Resource, id any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !b.SoftDeleteField.Valid {
+		return fmt.Errorf("%w: resource has no soft delete field", repository.ErrInvalidQuery)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t := r.readTable(b.Table()); t == nil || !memquery.Deleted(b, t.rows[key(id)]) {
+		return fmt.Errorf("%w: no rows affected", repository.ErrNotFound)
+	}
+	t := r.writeTable(b.Table())
+
+	err := r.record(record{Op: opDelete, Table: b.Table(), Key: key(id)})
+	if err != nil {
+		return err
+	}
+	delete(t.rows, key(id))
 	return nil
 }
@@ -1,13 +1,36 @@
 package local
 
 import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
 	"github.com/franciscoescher/gosimplerest/resource"
 )
 
-func (r Repository) Find(b *resource.Resource, id any) (map[string]any, error) {
-	row, ok := r.data[id]
-	if !ok {
+func (r *Repository) Find(ctx context.Context, b *resource.Resource, id any, opts repository.FindOptions) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	columns, err := repository.Columns(b, opts.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t := r.readTable(b.Table())
+	if t == nil {
+		return make(map[string]any, 0), nil
+	}
+	row, ok := t.rows[key(id)]
+	if !ok || (!opts.IncludeDeleted && memquery.Deleted(b, row)) {
 		return make(map[string]any, 0), nil
 	}
-	return row, nil
+	row, err = b.ScanRow(copyRow(row))
+	if err != nil {
+		return nil, err
+	}
+	return memquery.Project([]map[string]any{row}, columns)[0], nil
 }
@@ -1,38 +1,54 @@
 package local
 
 import (
-	"errors"
+	"context"
+	"fmt"
 
+	"github.com/franciscoescher/gosimplerest/repository"
 	"github.com/franciscoescher/gosimplerest/resource"
 )
 
-func (r Repository) Insert(b *resource.Resource, data map[string]any) (int64, error) {
+func (r *Repository) Insert(ctx context.Context, b *resource.Resource, data map[string]any) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.writeTable(b.Table())
+	row := copyRow(data)
 
 	var pk any
 	if b.AutoIncrementalPK {
-		r.maxPK = r.maxPK + 1
-		pk = r.maxPK
+		pk = t.maxPK + 1
+		row[b.PrimaryKey] = pk
 	} else {
-		for key, element := range data {
-			if key == b.PrimaryKey && !b.AutoIncrementalPK {
-				pk = element
-			}
-		}
+		pk = data[b.PrimaryKey]
 	}
 
 	if pk == nil {
-		return 0, errors.New("primary key not found")
+		return 0, fmt.Errorf("%w: primary key not found", repository.ErrInvalidQuery)
 	}
 
 	// checks if pk already exists
-	if _, ok := r.data[pk]; ok {
-		return 0, errors.New("primary key already exists")
+	if _, ok := t.rows[key(pk)]; ok {
+		return 0, fmt.Errorf("%w: primary key already exists", repository.ErrConflict)
+	}
+
+	maxPK := t.maxPK
+	if b.AutoIncrementalPK {
+		maxPK = pk.(int64)
+	}
+	err := r.record(record{Op: opInsert, Table: b.Table(), Key: key(pk), Row: row, MaxPK: maxPK})
+	if err != nil {
+		return 0, err
 	}
 
-	r.data[pk] = data
+	t.rows[key(pk)] = row
+	t.maxPK = maxPK
 
 	if b.AutoIncrementalPK {
-		return pk.(int64), nil
+		return t.maxPK, nil
 	}
 	return 0, nil
 }
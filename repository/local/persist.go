@@ -0,0 +1,306 @@
+package local
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
+)
+
+const (
+	// DefaultSnapshotInterval is the number of logged entries after which a snapshot is written
+	DefaultSnapshotInterval = 1000
+	// snapshotFile is the name of the file with the compacted data
+	snapshotFile = "snapshot.json"
+	// logFile is the name of the append-only log of the operations since the last snapshot
+	logFile = "oplog.jsonl"
+)
+
+// PersistOptions configures the persistence of a local repository to disk
+type PersistOptions struct {
+	// Dir is the directory where the snapshot and the log are stored, it is created if missing
+	Dir string
+	// SnapshotInterval is the number of logged entries after which the data is compacted
+	// into a snapshot and the log is truncated, before the next entry is logged.
+	// If 0, DefaultSnapshotInterval is used
+	SnapshotInterval int
+	// Sync flushes the log to the disk after each entry, so no operation is lost
+	// if the machine crashes, at the cost of slower writes
+	Sync bool
+}
+
+// Operations of the log
+const (
+	opInsert = "insert"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// record is an operation on a row. Insert and update records carry the whole row
+// after the operation, so replaying a record more than once has the same result.
+type record struct {
+	Op    string         `json:"op"`
+	Table string         `json:"table"`
+	Key   string         `json:"key"`
+	Row   map[string]any `json:"row,omitempty"`
+	MaxPK int64          `json:"max_pk,omitempty"`
+}
+
+// entry is a line of the log, with the records of an operation or of a whole transaction,
+// so a transaction is either fully replayed or not at all
+type entry struct {
+	Records []record `json:"records"`
+}
+
+// snapshot is the content of the snapshot file
+type snapshot struct {
+	Tables map[string]snapshotTable `json:"tables"`
+}
+
+type snapshotTable struct {
+	Rows  map[string]map[string]any `json:"rows"`
+	MaxPK int64                     `json:"max_pk"`
+}
+
+// store writes the operations of a repository to disk
+type store struct {
+	opts    PersistOptions
+	log     *os.File
+	entries int
+}
+
+// OpenRepository returns a local Repository persisted to the directory of the options.
+// The data is loaded from the last snapshot and the log is replayed over it.
+// Close should be called to write a final snapshot and release the files.
+func OpenRepository(opts PersistOptions) (*Repository, error) {
+	if opts.SnapshotInterval <= 0 {
+		opts.SnapshotInterval = DefaultSnapshotInterval
+	}
+	err := os.MkdirAll(opts.Dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewRepository()
+	err = r.loadSnapshot(filepath.Join(opts.Dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	entries, size, err := r.replayLog(filepath.Join(opts.Dir, logFile))
+	if err != nil {
+		return nil, err
+	}
+	// drops an incomplete last line, so new entries start on a line of their own
+	err = truncateIfExists(filepath.Join(opts.Dir, logFile), size)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(opts.Dir, logFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r.store = &store{opts: opts, log: f, entries: entries}
+	return r, nil
+}
+
+// Snapshot compacts the data into the snapshot file and truncates the log.
+// It does nothing if the repository is not persisted.
+func (r *Repository) Snapshot() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot()
+}
+
+// Close writes a snapshot and closes the log.
+// It does nothing if the repository is not persisted.
+func (r *Repository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.store == nil {
+		return nil
+	}
+	err := r.snapshot()
+	if err != nil {
+		return err
+	}
+	err = r.store.log.Close()
+	r.store = nil
+	return err
+}
+
+// record logs the records of an operation. In a transaction, they are kept
+// until the transaction is committed. The caller must hold the write lock.
+func (r *Repository) record(records ...record) error {
+	if r.parent != nil {
+		r.pending = append(r.pending, records...)
+		return nil
+	}
+	if r.store == nil || len(records) == 0 {
+		return nil
+	}
+
+	// the callers apply the records after they are logged, so the snapshot is written
+	// before the next entry, when the tables have all the logged operations
+	if r.store.entries >= r.store.opts.SnapshotInterval {
+		err := r.snapshot()
+		if err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(entry{Records: records})
+	if err != nil {
+		return err
+	}
+	_, err = r.store.log.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+	if r.store.opts.Sync {
+		err = r.store.log.Sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	r.store.entries++
+	return nil
+}
+
+// snapshot writes the tables to a temporary file, which atomically replaces
+// the snapshot file before the log is truncated. The caller must hold the write lock.
+func (r *Repository) snapshot() error {
+	if r.store == nil {
+		return nil
+	}
+
+	s := snapshot{Tables: make(map[string]snapshotTable, len(r.tables))}
+	for name, t := range r.tables {
+		s.Tables[name] = snapshotTable{Rows: t.rows, MaxPK: t.maxPK}
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(r.store.opts.Dir, snapshotFile)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return err
+	}
+
+	// replaying the log over the new snapshot is harmless, so a crash
+	// before the truncation does not corrupt the data
+	err = r.store.log.Truncate(0)
+	if err != nil {
+		return err
+	}
+	r.store.entries = 0
+	return nil
+}
+
+// loadSnapshot loads the tables from the snapshot file, if it exists
+func (r *Repository) loadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var s snapshot
+	err = memquery.DecodeJSON(data, &s)
+	if err != nil {
+		return err
+	}
+	for name, st := range s.Tables {
+		t := newTable()
+		t.maxPK = st.MaxPK
+		for k, row := range st.Rows {
+			t.rows[k] = row
+		}
+		r.tables[name] = t
+	}
+	return nil
+}
+
+// replayLog applies the entries of the log file, if it exists, returning how many were
+// read and the size of the complete lines. A last incomplete line, left by a crash
+// while writing, is ignored.
+func (r *Repository) replayLog(path string) (int, int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	entries := 0
+	size := int64(0)
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return entries, size, nil
+		}
+		if err != nil {
+			return entries, size, err
+		}
+		var e entry
+		err = memquery.DecodeJSON(line, &e)
+		if err != nil {
+			return entries, size, err
+		}
+		for _, rec := range e.Records {
+			r.apply(rec)
+		}
+		entries++
+		size += int64(len(line))
+	}
+}
+
+// truncateIfExists truncates the file to the given size, if it exists
+func truncateIfExists(path string, size int64) error {
+	err := os.Truncate(path, size)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// apply applies a record to the tables
+func (r *Repository) apply(rec record) {
+	t := r.writeTable(rec.Table)
+	switch rec.Op {
+	case opInsert, opUpdate:
+		t.rows[rec.Key] = rec.Row
+	case opDelete:
+		delete(t.rows, rec.Key)
+	}
+	if rec.MaxPK > t.maxPK {
+		t.maxPK = rec.MaxPK
+	}
+}
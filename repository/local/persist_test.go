@@ -0,0 +1,161 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistReplaysLog(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	r, err := OpenRepository(PersistOptions{Dir: dir})
+	assert.NoError(t, err)
+	_, err = r.Insert(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Fulano"})
+	assert.NoError(t, err)
+	_, err = r.Insert(ctx, &testResource, map[string]any{"uuid": "2", "first_name": "Ciclano"})
+	assert.NoError(t, err)
+	_, err = r.Update(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Beltrano"})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Delete(ctx, &testResource, "2"))
+	id, err := r.Insert(ctx, &autoIncrementResource, map[string]any{"name": "event"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	// reopens without closing, as after a crash, so only the log has the data
+	r, err = OpenRepository(PersistOptions{Dir: dir})
+	assert.NoError(t, err)
+	defer r.Close()
+
+	row, _ := r.Find(ctx, &testResource, "1", repository.FindOptions{})
+	assert.Equal(t, "Beltrano", row["first_name"])
+	row, _ = r.Find(ctx, &testResource, "2", repository.FindOptions{})
+	assert.Len(t, row, 0)
+	row, _ = r.Find(ctx, &autoIncrementResource, int64(1), repository.FindOptions{})
+	assert.Equal(t, int64(1), row["id"])
+
+	// the auto incremental counter is restored
+	id, err = r.Insert(ctx, &autoIncrementResource, map[string]any{"name": "event"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), id)
+}
+
+func TestPersistSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	r, err := OpenRepository(PersistOptions{Dir: dir, SnapshotInterval: 2})
+	assert.NoError(t, err)
+	for _, pk := range []string{"1", "2", "3"} {
+		_, err = r.Insert(ctx, &testResource, map[string]any{"uuid": pk, "first_name": "Fulano"})
+		assert.NoError(t, err)
+	}
+
+	// the log was compacted before the third insert
+	_, err = os.Stat(filepath.Join(dir, snapshotFile))
+	assert.NoError(t, err)
+	log, err := os.ReadFile(filepath.Join(dir, logFile))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countLines(log))
+
+	assert.NoError(t, r.Close())
+	log, err = os.ReadFile(filepath.Join(dir, logFile))
+	assert.NoError(t, err)
+	assert.Len(t, log, 0)
+
+	r, err = OpenRepository(PersistOptions{Dir: dir})
+	assert.NoError(t, err)
+	defer r.Close()
+	rows, err := r.Search(ctx, &testResource, repository.Query{Where: map[string][]any{"first_name": {"Fulano"}}})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+}
+
+func TestPersistSnapshotWithoutClose(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	r, err := OpenRepository(PersistOptions{Dir: dir, SnapshotInterval: 2})
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = r.Insert(ctx, &autoIncrementResource, map[string]any{"name": "event"})
+		assert.NoError(t, err)
+	}
+	err = r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		_, err := tx.Insert(ctx, &autoIncrementResource, map[string]any{"name": "event"})
+		return err
+	})
+	assert.NoError(t, err)
+
+	// reopens without closing, as after a crash, so no operation at the
+	// snapshot interval is left out of both the snapshot and the log
+	r, err = OpenRepository(PersistOptions{Dir: dir, SnapshotInterval: 2})
+	assert.NoError(t, err)
+	defer r.Close()
+	rows, err := r.Search(ctx, &autoIncrementResource, repository.Query{})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	id, err := r.Insert(ctx, &autoIncrementResource, map[string]any{"name": "event"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), id)
+}
+
+func TestPersistTransactions(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	r, err := OpenRepository(PersistOptions{Dir: dir})
+	assert.NoError(t, err)
+	err = r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		_, err := tx.Insert(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Fulano"})
+		return err
+	})
+	assert.NoError(t, err)
+	err = r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		_, err := tx.Insert(ctx, &testResource, map[string]any{"uuid": "2", "first_name": "Ciclano"})
+		if err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	// a crash while writing leaves an incomplete line at the end of the log
+	f, err := os.OpenFile(filepath.Join(dir, logFile), os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"records":[{"op":"insert","tab`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	r, err = OpenRepository(PersistOptions{Dir: dir})
+	assert.NoError(t, err)
+	row, _ := r.Find(ctx, &testResource, "1", repository.FindOptions{})
+	assert.Equal(t, "Fulano", row["first_name"])
+	row, _ = r.Find(ctx, &testResource, "2", repository.FindOptions{})
+	assert.Len(t, row, 0)
+
+	// new entries are still readable after the incomplete line was dropped
+	_, err = r.Insert(ctx, &testResource, map[string]any{"uuid": "3", "first_name": "Beltrano"})
+	assert.NoError(t, err)
+	r, err = OpenRepository(PersistOptions{Dir: dir})
+	assert.NoError(t, err)
+	defer r.Close()
+	row, _ = r.Find(ctx, &testResource, "3", repository.FindOptions{})
+	assert.Equal(t, "Beltrano", row["first_name"])
+}
+
+func countLines(data []byte) int {
+	n := 0
+	for _, c := range data {
+		if c == '\n' {
+			n++
+		}
+	}
+	return n
+}
@@ -1,31 +1,116 @@
 package local
 
 import (
+	"sync"
+
 	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
 )
 
 // Repository is the implementation of the RepositoryInterface for local in memory database.
-// It is a simple map of maps where the key of external map is the primary key
-// and the key of the internal map is the column name.
+// Each resource table is a map where the key is the primary key and the value is
+// the row, a map where the key is the column name.
+// It is safe for concurrent use and only hands out copies of the stored rows.
 // Only use it for testing purposes.
 type Repository struct {
-	// data is the local database
-	data map[any]map[string]any
+	mu sync.RWMutex
+	// tables is the local database, keyed by table name
+	tables map[string]*table
+	// parent is the repository a transaction was started from, nil outside transactions
+	parent *Repository
+	// pending are the records of a transaction, logged when it is committed
+	pending []record
+	// store persists the operations to disk, nil if the repository is only in memory
+	store *store
+}
+
+// table holds the rows of a resource table
+type table struct {
+	// rows are keyed by the string representation of the primary key,
+	// so a key read from an url matches the key of the stored row
+	rows map[string]map[string]any
 	// pk counter in case auto incremental pk
 	maxPK int64
 }
 
-// NewRepository returns a new local Repository
-func NewRepository() Repository {
-	return Repository{data: make(map[any]map[string]any, 0)}
+// NewRepository returns a new local Repository, kept only in memory.
+// Use OpenRepository for a repository persisted to disk.
+func NewRepository() *Repository {
+	return &Repository{tables: make(map[string]*table, 0)}
 }
 
-// NewRepository returns a new local Repository
-func NewRepositoryWithData(data map[any]map[string]any) Repository {
-	return Repository{
-		data: data,
+// NewRepositoryWithData returns a new local Repository with the given data,
+// which is keyed by table name and then by primary key
+func NewRepositoryWithData(data map[string]map[any]map[string]any) *Repository {
+	r := NewRepository()
+	for name, rows := range data {
+		t := newTable()
+		for pk, row := range rows {
+			t.rows[key(pk)] = copyRow(row)
+			if n, ok := pk.(int64); ok && n > t.maxPK {
+				t.maxPK = n
+			}
+		}
+		r.tables[name] = t
 	}
+	return r
 }
 
 // Compile-time check that Repository implements the Repository interface
 var _ repository.RepositoryInterface = (*Repository)(nil)
+
+func newTable() *table {
+	return &table{rows: make(map[string]map[string]any, 0)}
+}
+
+// readTable returns the table with the given name for reading, or nil if it was never written.
+// The caller must hold the lock of the repository.
+func (r *Repository) readTable(name string) *table {
+	if t, ok := r.tables[name]; ok {
+		return t
+	}
+	if r.parent != nil {
+		return r.parent.tables[name]
+	}
+	return nil
+}
+
+// writeTable returns the table with the given name for writing, creating it if needed.
+// In a transaction, the table of the parent repository is copied on the first write.
+// The caller must hold the write lock of the repository.
+func (r *Repository) writeTable(name string) *table {
+	if t, ok := r.tables[name]; ok {
+		return t
+	}
+	t := newTable()
+	if r.parent != nil {
+		if pt, ok := r.parent.tables[name]; ok {
+			t = pt.copy()
+		}
+	}
+	r.tables[name] = t
+	return t
+}
+
+// copy returns a copy of the table, with the rows also copied
+func (t *table) copy() *table {
+	c := &table{rows: make(map[string]map[string]any, len(t.rows)), maxPK: t.maxPK}
+	for k, row := range t.rows {
+		c.rows[k] = copyRow(row)
+	}
+	return c
+}
+
+// key returns the key of a primary key value in the rows map
+func key(pk any) string {
+	return memquery.Key(pk)
+}
+
+// copyRow returns a copy of the row
+func copyRow(row map[string]any) map[string]any {
+	c := make(map[string]any, len(row))
+	for k, v := range row {
+		c[k] = v
+	}
+	return c
+}
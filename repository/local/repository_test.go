@@ -0,0 +1,128 @@
+package local
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+var autoIncrementResource = resource.Resource{
+	Name:              "events_test",
+	PrimaryKey:        "id",
+	AutoIncrementalPK: true,
+	Fields: map[string]resource.Field{
+		"id":   {},
+		"name": {},
+	},
+}
+
+func TestResourcesDoNotCollide(t *testing.T) {
+	r := NewRepository()
+	ctx := context.Background()
+	other := testResource
+	other.Name = "vehicles_test"
+
+	_, err := r.Insert(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Fulano"})
+	assert.NoError(t, err)
+	_, err = r.Insert(ctx, &other, map[string]any{"uuid": "1", "first_name": "Ciclano"})
+	assert.NoError(t, err)
+
+	row, _ := r.Find(ctx, &testResource, "1", repository.FindOptions{})
+	assert.Equal(t, "Fulano", row["first_name"])
+	row, _ = r.Find(ctx, &other, "1", repository.FindOptions{})
+	assert.Equal(t, "Ciclano", row["first_name"])
+}
+
+func TestRowsAreCopied(t *testing.T) {
+	r := NewRepository()
+	ctx := context.Background()
+	data := map[string]any{"uuid": "1", "first_name": "Fulano"}
+	_, err := r.Insert(ctx, &testResource, data)
+	assert.NoError(t, err)
+
+	data["first_name"] = "Ciclano"
+	row, _ := r.Find(ctx, &testResource, "1", repository.FindOptions{})
+	assert.Equal(t, "Fulano", row["first_name"])
+
+	row["first_name"] = "Beltrano"
+	rows, _ := r.Search(ctx, &testResource, repository.Query{Where: map[string][]any{"uuid": {"1"}}})
+	rows[0]["first_name"] = "Beltrano"
+	row, _ = r.Find(ctx, &testResource, "1", repository.FindOptions{})
+	assert.Equal(t, "Fulano", row["first_name"])
+}
+
+func TestConcurrentInserts(t *testing.T) {
+	r := NewRepository()
+	ctx := context.Background()
+
+	const n = 100
+	ids := make(chan int64, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := r.Insert(ctx, &autoIncrementResource, map[string]any{"name": "event"})
+			assert.NoError(t, err)
+			_, err = r.Find(ctx, &autoIncrementResource, id, repository.FindOptions{})
+			assert.NoError(t, err)
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, n)
+	for id := range ids {
+		assert.False(t, seen[id], "duplicated id %d", id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, n)
+
+	// the primary key read from an url is a string
+	row, _ := r.Find(ctx, &autoIncrementResource, "1", repository.FindOptions{})
+	assert.Equal(t, int64(1), row["id"])
+}
+
+func TestInvalidIntValues(t *testing.T) {
+	r := NewRepository()
+	ctx := context.Background()
+	b := resource.Resource{Name: "counters_test", PrimaryKey: "id", Fields: map[string]resource.Field{"id": {Type: resource.TypeInt}}}
+	_, err := r.Insert(ctx, &b, map[string]any{"id": int64(1)})
+	assert.NoError(t, err)
+
+	// floats that are not whole or do not fit an int64 are not converted
+	for _, v := range []any{1.5, 1e20} {
+		_, err = r.Search(ctx, &b, repository.Query{Filters: []repository.Filter{{Field: "id", Op: resource.OpGt, Values: []any{v}}}})
+		assert.ErrorIs(t, err, repository.ErrInvalidQuery, "%v", v)
+		_, err = r.Search(ctx, &b, repository.Query{Where: map[string][]any{"id": {v}}})
+		assert.ErrorIs(t, err, repository.ErrInvalidQuery, "%v", v)
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	r := NewRepository()
+	_, err := r.Insert(context.Background(), &testResource, map[string]any{"uuid": "1", "first_name": "Fulano"})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the operations return the error of the context, as the other repositories do
+	_, err = r.Insert(ctx, &testResource, map[string]any{"uuid": "2", "first_name": "Ciclano"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = r.Update(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Ciclano"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, r.Delete(ctx, &testResource, "1"), context.Canceled)
+	_, err = r.Find(ctx, &testResource, "1", repository.FindOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = r.Search(ctx, &testResource, repository.Query{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	rows, err := r.Search(context.Background(), &testResource, repository.Query{})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"uuid": "1", "first_name": "Fulano"}}, rows)
+}
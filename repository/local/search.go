@@ -1,23 +1,91 @@
 package local
 
 import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
 	"github.com/franciscoescher/gosimplerest/resource"
 )
 
-func (r Repository) Search(b *resource.Resource, query map[string][]string) ([]map[string]any, error) {
-	results := make([]map[string]any, 0)
+func (r *Repository) Search(ctx context.Context, b *resource.Resource, q repository.Query) ([]map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	columns, err := repository.Columns(b, q.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results, err := r.matching(b, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// rows are ordered by the sort of the query and the primary key, as in the sql repositories
+	memquery.Sort(results, q.Order(b.PrimaryKey))
+	results, err = memquery.Paginate(b, results, q)
+	if err != nil {
+		return nil, repository.NewError(repository.ErrInvalidQuery, err)
+	}
+	return memquery.Project(results, columns), nil
+}
+
+func (r *Repository) Count(ctx context.Context, b *resource.Resource, q repository.Query) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	for _, row := range r.data {
-		match := false
-		for field, value := range query {
-			for _, v := range value {
-				if row[field] == v {
-					match = true
-					break
-				}
-			}
+	results, err := r.matching(b, q)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(results)), nil
+}
+
+// matching returns copies of the rows matching the where, filters, expression, full-text search and soft deletes of the query
+func (r *Repository) matching(b *resource.Resource, q repository.Query) ([]map[string]any, error) {
+	query, err := memquery.ScanQuery(b, q.Where)
+	if err != nil {
+		return nil, repository.NewError(repository.ErrInvalidQuery, err)
+	}
+	filters, err := memquery.ScanFilters(b, q.Filters)
+	if err != nil {
+		return nil, err
+	}
+	e, err := memquery.ResolveRelated(b, q.Expr, r.matching)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := memquery.ScanExpr(b, e)
+	if err != nil {
+		return nil, err
+	}
+	err = repository.ValidateText(b, q.Text)
+	if err != nil {
+		return nil, err
+	}
+	terms := repository.TextTerms(q.Text)
+
+	results := make([]map[string]any, 0)
+	t := r.readTable(b.Table())
+	if t == nil {
+		return results, nil
+	}
+	for _, row := range t.rows {
+		if !q.IncludeDeleted && memquery.Deleted(b, row) {
+			continue
+		}
+		row, err := b.ScanRow(copyRow(row))
+		if err != nil {
+			return nil, err
 		}
-		if match {
+		if memquery.Matches(row, query) && memquery.MatchesFilters(row, filters) && expr.Matches(row) && memquery.MatchesText(b, row, terms) {
 			results = append(results, row)
 		}
 	}
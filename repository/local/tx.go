@@ -0,0 +1,105 @@
+package local
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Compile-time check that Repository implements the Transactional and ReadTransactional interfaces
+var _ repository.Transactional = (*Repository)(nil)
+var _ repository.ReadTransactional = (*Repository)(nil)
+
+// errReadOnly is returned by the writes of read-only transactions
+var errReadOnly = fmt.Errorf("%w: read-only transaction", repository.ErrInvalidQuery)
+
+// WithTx runs fn holding the write lock of the repository, so transactions are serialized.
+// The repository passed to fn copies a table on its first write to it, and the copies
+// replace the tables of the repository only if fn returns nil and ctx is not done.
+// If the repository is persisted, the operations of the transaction are logged together.
+// fn must only use the repository it receives, since the original one is locked.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	if r.parent != nil {
+		return fn(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &Repository{tables: make(map[string]*table, 0), parent: r}
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err = r.record(tx.pending...)
+	if err != nil {
+		return err
+	}
+	for name, t := range tx.tables {
+		r.tables[name] = t
+	}
+	return nil
+}
+
+// WithReadTx runs fn holding the read lock of the repository, so it runs along with the
+// other reads and read-only transactions, but not with writes.
+// fn must only use the repository it receives, since the original one is locked.
+func (r *Repository) WithReadTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	if r.parent != nil {
+		return fn(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	err := fn(readTx{&Repository{tables: make(map[string]*table, 0), parent: r}})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// readTx is the repository of a read-only transaction, which reads the tables
+// of its parent and whose writes fail
+type readTx struct {
+	*Repository
+}
+
+func (tx readTx) Insert(ctx context.Context, b *resource.Resource, data map[string]any) (int64, error) {
+	return 0, errReadOnly
+}
+
+func (tx readTx) Update(ctx context.Context, b *resource.Resource, data map[string]any) (bool, error) {
+	return false, errReadOnly
+}
+
+func (tx readTx) Delete(ctx context.Context, b *resource.Resource, id any) error {
+	return errReadOnly
+}
+
+func (tx readTx) Restore(ctx context.Context, b *resource.Resource, id any) error {
+	return errReadOnly
+}
+
+func (tx readTx) Purge(ctx context.Context, b *resource.Resource, id any) error {
+	return errReadOnly
+}
+
+func (tx readTx) WithTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	return fn(tx)
+}
+
+func (tx readTx) WithReadTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	return fn(tx)
+}
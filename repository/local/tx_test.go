@@ -0,0 +1,111 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+var testResource = resource.Resource{
+	Name:       "users_test",
+	PrimaryKey: "uuid",
+	Fields: map[string]resource.Field{
+		"uuid":       {},
+		"first_name": {},
+	},
+}
+
+func TestWithTx(t *testing.T) {
+	r := NewRepository()
+	ctx := context.Background()
+	_, err := r.Insert(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Fulano"})
+	assert.NoError(t, err)
+
+	// rolled back when fn returns an error
+	errRollback := errors.New("rollback")
+	err = r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		_, err := tx.Insert(ctx, &testResource, map[string]any{"uuid": "2", "first_name": "Ciclano"})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Update(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Beltrano"})
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	row, _ := r.Find(ctx, &testResource, "1", repository.FindOptions{})
+	assert.Equal(t, "Fulano", row["first_name"])
+	row, _ = r.Find(ctx, &testResource, "2", repository.FindOptions{})
+	assert.Len(t, row, 0)
+
+	// committed when fn returns nil
+	err = r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		_, err := tx.Insert(ctx, &testResource, map[string]any{"uuid": "2", "first_name": "Ciclano"})
+		return err
+	})
+	assert.NoError(t, err)
+	row, _ = r.Find(ctx, &testResource, "2", repository.FindOptions{})
+	assert.Equal(t, "Ciclano", row["first_name"])
+}
+
+func TestWithTxContext(t *testing.T) {
+	r := NewRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// not committed if ctx is done when fn returns
+	err := r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		_, err := tx.Insert(ctx, &testResource, map[string]any{"uuid": "1", "first_name": "Fulano"})
+		cancel()
+		return err
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	row, _ := r.Find(context.Background(), &testResource, "1", repository.FindOptions{})
+	assert.Len(t, row, 0)
+
+	// fn is not run if ctx is already done
+	err = r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		t.Fatal("fn should not run")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWithReadTxRunsConcurrently(t *testing.T) {
+	r := NewRepository()
+	ctx := context.Background()
+
+	// a read-only transaction starts while another one is still running
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- r.WithReadTx(ctx, func(tx repository.RepositoryInterface) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	ran := make(chan error, 1)
+	go func() {
+		ran <- r.WithReadTx(ctx, func(tx repository.RepositoryInterface) error {
+			_, err := tx.Find(ctx, &testResource, "1", repository.FindOptions{})
+			return err
+		})
+	}()
+	select {
+	case err := <-ran:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("read-only transactions were serialized")
+	}
+	close(release)
+	assert.NoError(t, <-done)
+}
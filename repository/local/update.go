@@ -1,30 +1,42 @@
 package local
 
 import (
-	"errors"
+	"context"
+	"fmt"
 
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/memquery"
 	"github.com/franciscoescher/gosimplerest/resource"
 )
 
-func (r Repository) Update(b *resource.Resource, data map[string]any) (bool, error) {
-
+func (r *Repository) Update(ctx context.Context, b *resource.Resource, data map[string]any) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
 	// if the primary key is not in the data, return false
 	if _, ok := data[b.PrimaryKey]; !ok {
-		return false, errors.New("primary key not in data")
+		return false, fmt.Errorf("%w: primary key not in data", repository.ErrInvalidQuery)
 	}
 
-	inPlaceData, ok := r.data[data[b.PrimaryKey]]
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// if the row does not exist, return false
-	if !ok {
+	// if the row does not exist or is soft deleted, return false
+	pk := key(data[b.PrimaryKey])
+	if t := r.readTable(b.Table()); t == nil || t.rows[pk] == nil || memquery.Deleted(b, t.rows[pk]) {
 		return false, nil
 	}
 
+	t := r.writeTable(b.Table())
+	updated := copyRow(t.rows[pk])
 	for key, element := range data {
-		inPlaceData[key] = element
+		updated[key] = element
 	}
-
-	r.data[data[b.PrimaryKey]] = inPlaceData
+	err := r.record(record{Op: opUpdate, Table: b.Table(), Key: pk, Row: updated})
+	if err != nil {
+		return false, err
+	}
+	t.rows[pk] = updated
 
 	return true, nil
 }
@@ -0,0 +1,164 @@
+package memquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// decimalAvgScale is the number of digits added to the scale of the values of a decimal
+// field for their average, as mysql does
+const decimalAvgScale = 4
+
+// Aggregate groups the rows by the group fields of the query and computes its aggregates
+// for each group, as the sql repositories do. The rows must match the query and have the
+// types of the fields, as returned by resource.ScanRow.
+func Aggregate(b *resource.Resource, rows []map[string]any, q repository.AggregateQuery) ([]map[string]any, error) {
+	groups := make(map[string][]*accumulator)
+	results := make([]map[string]any, 0)
+	for _, row := range rows {
+		k := groupKey(row, q.GroupBy)
+		accs, ok := groups[k]
+		if !ok {
+			result := make(map[string]any, len(q.GroupBy)+len(q.Aggregates))
+			for _, field := range q.GroupBy {
+				result[field] = row[field]
+			}
+			results = append(results, result)
+			accs = newAccumulators(b, q.Aggregates)
+			groups[k] = accs
+		}
+		for _, acc := range accs {
+			err := acc.add(row)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// without group fields, all the rows are a single group, even if there are none
+	if len(q.GroupBy) == 0 && len(results) == 0 {
+		results = append(results, make(map[string]any, len(q.Aggregates)))
+		groups[groupKey(nil, nil)] = newAccumulators(b, q.Aggregates)
+	}
+
+	for _, result := range results {
+		for _, acc := range groups[groupKey(result, q.GroupBy)] {
+			result[acc.Name()] = acc.result()
+		}
+	}
+	order := make([]repository.Sort, len(q.GroupBy))
+	for i, field := range q.GroupBy {
+		order[i] = repository.Sort{Field: field}
+	}
+	Sort(results, order)
+	return results, nil
+}
+
+// groupKey returns the key of the group of the row, from the values of the group fields
+func groupKey(row map[string]any, fields []string) string {
+	var sb strings.Builder
+	for _, field := range fields {
+		// null values are a group of their own, apart from the values written as null
+		if v := row[field]; v == nil {
+			sb.WriteString("\x00")
+		} else {
+			sb.WriteString("v")
+			sb.WriteString(Key(v))
+		}
+		sb.WriteString("\x01")
+	}
+	return sb.String()
+}
+
+// accumulator computes an aggregate over the rows of a group
+type accumulator struct {
+	repository.Aggregate
+	t     resource.Type
+	count int64
+	sum   *big.Rat
+	// scale is the highest number of decimal digits of the values of a decimal field
+	scale int
+	// best is the lowest or highest value, for min and max
+	best any
+}
+
+func newAccumulators(b *resource.Resource, aggregates []repository.Aggregate) []*accumulator {
+	accs := make([]*accumulator, len(aggregates))
+	for i, a := range aggregates {
+		accs[i] = &accumulator{Aggregate: a, t: b.Fields[a.Field].Type, sum: new(big.Rat)}
+	}
+	return accs
+}
+
+// add adds the value of the field of the row to the aggregate, ignoring null values
+func (acc *accumulator) add(row map[string]any) error {
+	if acc.Field == repository.AllRows {
+		acc.count++
+		return nil
+	}
+	v := row[acc.Field]
+	if v == nil {
+		return nil
+	}
+	acc.count++
+	switch acc.Func {
+	case repository.AggSum, repository.AggAvg:
+		s := Key(v)
+		n, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return repository.NewError(repository.ErrInvalidQuery, fmt.Errorf("field %s: invalid number %s", acc.Field, s))
+		}
+		acc.sum.Add(acc.sum, n)
+		if i := strings.IndexByte(s, '.'); i >= 0 && len(s)-i-1 > acc.scale {
+			acc.scale = len(s) - i - 1
+		}
+	case repository.AggMin:
+		if acc.best == nil || Compare(v, acc.best) < 0 {
+			acc.best = v
+		}
+	case repository.AggMax:
+		if acc.best == nil || Compare(v, acc.best) > 0 {
+			acc.best = v
+		}
+	}
+	return nil
+}
+
+// result returns the value of the aggregate, with the type of Aggregate.Type,
+// or nil for the aggregates of fields other than counts without values
+func (acc *accumulator) result() any {
+	if acc.Func == repository.AggCount {
+		return acc.count
+	}
+	if acc.count == 0 {
+		return nil
+	}
+	switch acc.Func {
+	case repository.AggSum:
+		return ratValue(acc.sum, acc.t, acc.scale)
+	case repository.AggAvg:
+		avg := new(big.Rat).Quo(acc.sum, new(big.Rat).SetInt64(acc.count))
+		if acc.t == resource.TypeDecimal {
+			return json.Number(avg.FloatString(acc.scale + decimalAvgScale))
+		}
+		f, _ := avg.Float64()
+		return f
+	}
+	return acc.best
+}
+
+// ratValue converts a sum to the type of the field
+func ratValue(r *big.Rat, t resource.Type, scale int) any {
+	switch {
+	case t == resource.TypeDecimal:
+		return json.Number(r.FloatString(scale))
+	case t != resource.TypeFloat && r.IsInt() && r.Num().IsInt64():
+		return r.Num().Int64()
+	}
+	f, _ := r.Float64()
+	return f
+}
@@ -0,0 +1,158 @@
+package memquery
+
+import (
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// truth is the value of an expression in the three-valued logic of sql,
+// where comparisons with null values are unknown
+type truth int8
+
+const (
+	falseTruth truth = iota
+	unknownTruth
+	trueTruth
+)
+
+// Expr is a filter expression, with the values of its filters converted by ScanExpr
+type Expr struct {
+	eval func(row map[string]any) truth
+}
+
+// ScanExpr validates the expression and converts the values of its filters
+// to the types of the fields, as ScanFilters does. A nil expression matches all rows.
+func ScanExpr(b *resource.Resource, e repository.Expr) (Expr, error) {
+	err := repository.ValidateExpr(b, e)
+	if err != nil {
+		return Expr{}, err
+	}
+	if e == nil {
+		return Expr{}, nil
+	}
+	eval, err := scanExpr(b, e)
+	return Expr{eval: eval}, err
+}
+
+// ResolveRelated validates the expression and replaces its related expressions with in filters
+// of the keys of the rows of the other resource, read with search, so ScanExpr can scan it.
+// Null keys are left out, and a related expression without keys matches no rows.
+func ResolveRelated(b *resource.Resource, e repository.Expr, search func(b *resource.Resource, q repository.Query) ([]map[string]any, error)) (repository.Expr, error) {
+	err := repository.ValidateExpr(b, e)
+	if err != nil {
+		return nil, err
+	}
+	return resolveRelated(e, search)
+}
+
+func resolveRelated(e repository.Expr, search func(b *resource.Resource, q repository.Query) ([]map[string]any, error)) (repository.Expr, error) {
+	switch e := e.(type) {
+	case repository.And:
+		exprs, err := resolveExprs(e, search)
+		return repository.And(exprs), err
+	case repository.Or:
+		exprs, err := resolveExprs(e, search)
+		return repository.Or(exprs), err
+	case repository.Not:
+		expr, err := resolveRelated(e.Expr, search)
+		return repository.Not{Expr: expr}, err
+	case repository.Related:
+		rows, err := search(e.Resource, repository.Query{Where: e.Where})
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]any, 0, len(rows))
+		for _, row := range rows {
+			if key := row[e.Key]; key != nil {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			// no value is both null and not null
+			return repository.And{
+				repository.Filter{Field: e.Field, Op: resource.OpNull, Values: []any{true}},
+				repository.Filter{Field: e.Field, Op: resource.OpNull, Values: []any{false}},
+			}, nil
+		}
+		return repository.Filter{Field: e.Field, Op: resource.OpIn, Values: keys}, nil
+	}
+	return e, nil
+}
+
+// resolveExprs resolves the expressions of an And or an Or
+func resolveExprs(exprs []repository.Expr, search func(b *resource.Resource, q repository.Query) ([]map[string]any, error)) ([]repository.Expr, error) {
+	resolved := make([]repository.Expr, len(exprs))
+	for i, e := range exprs {
+		var err error
+		resolved[i], err = resolveRelated(e, search)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// Matches returns true if the row matches the expression. As in sql, rows for which
+// the expression is unknown, because of null values, do not match.
+func (e Expr) Matches(row map[string]any) bool {
+	return e.eval == nil || e.eval(row) == trueTruth
+}
+
+// scanExpr returns the function evaluating a node of a validated expression
+func scanExpr(b *resource.Resource, e repository.Expr) (func(row map[string]any) truth, error) {
+	switch e := e.(type) {
+	case repository.And:
+		return scanExprs(b, e, falseTruth)
+	case repository.Or:
+		return scanExprs(b, e, trueTruth)
+	case repository.Not:
+		eval, err := scanExpr(b, e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return func(row map[string]any) truth {
+			return trueTruth - eval(row)
+		}, nil
+	}
+	filters, err := ScanFilters(b, []repository.Filter{e.(repository.Filter)})
+	if err != nil {
+		return nil, err
+	}
+	f := filters[0]
+	return func(row map[string]any) truth {
+		v := row[f.Field]
+		if v == nil && f.Op != resource.OpNull {
+			return unknownTruth
+		}
+		if f.matches(v) {
+			return trueTruth
+		}
+		return falseTruth
+	}, nil
+}
+
+// scanExprs returns the function evaluating an And, which is decided by a false
+// expression, or an Or, which is decided by a true one. Otherwise it is unknown
+// if any of the expressions is unknown.
+func scanExprs(b *resource.Resource, exprs []repository.Expr, decisive truth) (func(row map[string]any) truth, error) {
+	evals := make([]func(row map[string]any) truth, len(exprs))
+	for i, e := range exprs {
+		var err error
+		evals[i], err = scanExpr(b, e)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(row map[string]any) truth {
+		result := trueTruth - decisive
+		for _, eval := range evals {
+			switch eval(row) {
+			case decisive:
+				return decisive
+			case unknownTruth:
+				result = unknownTruth
+			}
+		}
+		return result
+	}, nil
+}
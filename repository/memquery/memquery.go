@@ -0,0 +1,349 @@
+// Package memquery evaluates the queries of the repository interface over rows held in memory.
+// It is shared by the repositories that can not push queries down to a database engine,
+// so all of them behave like the sql repositories.
+package memquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Key returns the string representation of a value, used to compare the values
+// of the rows with the values of a query, which comes from an url
+func Key(v any) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case json.RawMessage:
+		return string(t)
+	case []byte:
+		return string(t)
+	}
+	return fmt.Sprint(v)
+}
+
+// Equal returns true if two values of a column are equal,
+// numerically if both are numbers and by their Key otherwise
+func Equal(a, b any) bool {
+	fa, aok := toFloat(a)
+	fb, bok := toFloat(b)
+	if aok && bok {
+		return fa == fb
+	}
+	return Key(a) == Key(b)
+}
+
+// ScanQuery converts the values of a query to the types of the fields, as returned by
+// resource.ScanRow, so they can be compared with the rows returned by the repositories
+func ScanQuery(b *resource.Resource, query map[string][]any) (map[string][]any, error) {
+	scanned := make(map[string][]any, len(query))
+	for field, values := range query {
+		scanned[field] = make([]any, len(values))
+		for i, v := range values {
+			s, err := b.Fields[field].Type.Scan(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field, err)
+			}
+			scanned[field][i] = s
+		}
+	}
+	return scanned, nil
+}
+
+// Matches returns true if the row matches the query: values of the same field
+// are ORed and different fields are ANDed.
+func Matches(row map[string]any, query map[string][]any) bool {
+	for field, values := range query {
+		found := false
+		for _, v := range values {
+			if row[field] != nil && Equal(row[field], v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter is a filter of a query, with its values converted by ScanFilters
+type Filter struct {
+	repository.Filter
+	// pattern is the like pattern, compiled to a regular expression
+	pattern *regexp.Regexp
+}
+
+// ScanFilters validates the filters and converts their values to the types of the fields,
+// as ScanQuery does, compiling the like patterns
+func ScanFilters(b *resource.Resource, filters []repository.Filter) ([]Filter, error) {
+	err := repository.ValidateFilters(b, filters)
+	if err != nil {
+		return nil, err
+	}
+	scanned := make([]Filter, len(filters))
+	for i, f := range filters {
+		scanned[i].Filter = f
+		switch f.Op {
+		case resource.OpNull:
+			continue
+		case resource.OpLike:
+			scanned[i].pattern, err = likePattern(Key(f.Values[0]))
+			if err != nil {
+				return nil, repository.NewError(repository.ErrInvalidQuery, err)
+			}
+			continue
+		}
+		scanned[i].Values = make([]any, len(f.Values))
+		for j, v := range f.Values {
+			scanned[i].Values[j], err = b.Fields[f.Field].Type.Scan(v)
+			if err != nil {
+				return nil, repository.NewError(repository.ErrInvalidQuery, fmt.Errorf("field %s: %w", f.Field, err))
+			}
+		}
+	}
+	return scanned, nil
+}
+
+// likePattern converts a like pattern to a regular expression that ignores case
+func likePattern(like string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString(`(?is)^`)
+	for _, r := range like {
+		switch r {
+		case '%':
+			sb.WriteString(`.*`)
+		case '_':
+			sb.WriteString(`.`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString(`$`)
+	return regexp.Compile(sb.String())
+}
+
+// MatchesFilters returns true if the row matches all the filters
+func MatchesFilters(row map[string]any, filters []Filter) bool {
+	for _, f := range filters {
+		if !f.matches(row[f.Field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns true if a value of the field matches the filter.
+// Null values only match the null operator, as in sql.
+func (f Filter) matches(v any) bool {
+	if f.Op == resource.OpNull {
+		return (v == nil) == f.Values[0].(bool)
+	}
+	if v == nil {
+		return false
+	}
+	switch f.Op {
+	case resource.OpLike:
+		return f.pattern.MatchString(Key(v))
+	case resource.OpIn, resource.OpNin:
+		found := false
+		for _, value := range f.Values {
+			if Equal(v, value) {
+				found = true
+				break
+			}
+		}
+		return found == (f.Op == resource.OpIn)
+	case resource.OpEq:
+		return Equal(v, f.Values[0])
+	case resource.OpNe:
+		return !Equal(v, f.Values[0])
+	}
+	c := Compare(v, f.Values[0])
+	switch f.Op {
+	case resource.OpGt:
+		return c > 0
+	case resource.OpGte:
+		return c >= 0
+	case resource.OpLt:
+		return c < 0
+	}
+	return c <= 0
+}
+
+// Deleted returns true if the row is soft deleted
+func Deleted(b *resource.Resource, row map[string]any) bool {
+	return b.SoftDeleteField.Valid && row[b.SoftDeleteField.String] != nil
+}
+
+// Project returns the rows with only the given columns, as returned by repository.Columns
+func Project(rows []map[string]any, columns []string) []map[string]any {
+	for i, row := range rows {
+		projected := make(map[string]any, len(columns))
+		for _, c := range columns {
+			if v, ok := row[c]; ok {
+				projected[c] = v
+			}
+		}
+		rows[i] = projected
+	}
+	return rows
+}
+
+// Sort sorts the rows by the fields of the order, as the sql repositories do
+func Sort(rows []map[string]any, order []repository.Sort) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return compareRow(rows[i], order, position(rows[j], order)) < 0
+	})
+}
+
+// Paginate returns the page of the rows selected by the limit, offset and cursor
+// of the query. The rows must be sorted by the order of the query.
+func Paginate(b *resource.Resource, rows []map[string]any, q repository.Query) ([]map[string]any, error) {
+	backwards := q.Cursor != nil && q.Cursor.Before
+	if q.Cursor != nil {
+		order := q.Order(b.PrimaryKey)
+		values, err := q.Position(b.PrimaryKey)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range order {
+			values[i], err = b.Fields[s.Field].Type.Scan(values[i])
+			if err != nil {
+				return nil, fmt.Errorf("cursor: field %s: %w", s.Field, err)
+			}
+		}
+		// the rows after the cursor start at the first row greater than the position,
+		// and the rows before it end at the first row greater or equal to the position
+		i := sort.Search(len(rows), func(i int) bool {
+			c := compareRow(rows[i], order, values)
+			if backwards {
+				return c >= 0
+			}
+			return c > 0
+		})
+		if backwards {
+			rows = rows[:i]
+		} else {
+			rows = rows[i:]
+		}
+	}
+
+	// rows before a cursor are counted from the end, the closest to the cursor
+	start, end := q.Offset, len(rows)
+	if backwards {
+		start, end = 0, len(rows)-q.Offset
+		if q.Limit > 0 && end-q.Limit > start {
+			start = end - q.Limit
+		}
+	} else if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+	}
+	if start >= end {
+		return rows[:0], nil
+	}
+	return rows[start:end], nil
+}
+
+// position returns the values of the row for the fields of the order
+func position(row map[string]any, order []repository.Sort) []any {
+	values := make([]any, len(order))
+	for i, s := range order {
+		values[i] = row[s.Field]
+	}
+	return values
+}
+
+// compareRow compares a row with the values of a position in the order,
+// returning -1 if the row comes before it, 0 if at it and 1 if after it
+func compareRow(row map[string]any, order []repository.Sort, values []any) int {
+	for i, s := range order {
+		c := Compare(row[s.Field], values[i])
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Less compares two values of a column, as Compare
+func Less(a, b any) bool {
+	return Compare(a, b) < 0
+}
+
+// Compare compares two values of a column, returning -1, 0 or 1.
+// Nil is lower than any value, numbers are compared numerically, times
+// chronologically and other values by their string representation.
+func Compare(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if fa, aok := toFloat(a); aok {
+		if fb, bok := toFloat(b); bok {
+			return compare(fa < fb, fa > fb)
+		}
+	}
+	if ta, aok := a.(time.Time); aok {
+		if tb, bok := b.(time.Time); bok {
+			return compare(ta.Before(tb), ta.After(tb))
+		}
+	}
+	ka, kb := Key(a), Key(b)
+	return compare(ka < kb, ka > kb)
+}
+
+// compare returns the result of a comparison from its less and greater results
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// DecodeJSON decodes the data keeping numbers as json.Number,
+// so integers do not become float64
+func DecodeJSON(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
@@ -0,0 +1,35 @@
+package memquery
+
+import (
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// MatchesText returns true if every term, as returned by repository.TextTerms,
+// starts a word of one of the full-text fields of the row
+func MatchesText(b *resource.Resource, row map[string]any, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	words := make([]string, 0)
+	for _, field := range b.FullTextFields() {
+		if v := row[field]; v != nil {
+			words = append(words, repository.TextTerms(Key(v))...)
+		}
+	}
+	for _, term := range terms {
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
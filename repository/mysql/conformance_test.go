@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/repositorytest"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+const dropConformanceTables = "DROP TABLE IF EXISTS `conformance_users`, `conformance_notes`, `conformance_events`, `conformance_orders`, `conformance_memberships`"
+
+// TestConformance runs against the database of the GOSIMPLEREST_MYSQL_DSN environment variable,
+// dropping and creating the tables of the suite, and is skipped if it is not set
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("GOSIMPLEREST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("GOSIMPLEREST_MYSQL_DSN is not set")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repositorytest.RunConformance(t, func(t *testing.T) repository.RepositoryInterface {
+		_, err := db.Exec(dropConformanceTables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = AutoMigrate(db, repositorytest.Resources()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewRepository(db)
+	})
+}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// Dialect is the MySQL syntax used by the sqlrepo query builder
+type Dialect struct{}
+
+// Compile-time check that Dialect implements the Dialect and TextDialect interfaces
+var _ sqlrepo.Dialect = Dialect{}
+var _ sqlrepo.TextDialect = Dialect{}
+
+func (Dialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (Dialect) Quote(identifier string) string {
+	return sqlrepo.QuoteIdentifier(identifier, "`")
+}
+
+func (Dialect) CurrentTimestamp() string {
+	return "NOW()"
+}
+
+// Limit uses the largest row count as limit when only the offset is given,
+// since MySQL does not accept an offset without a limit
+func (Dialect) Limit(limit, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	l := "18446744073709551615"
+	if limit > 0 {
+		l = strconv.Itoa(limit)
+	}
+	if offset <= 0 {
+		return "LIMIT " + l
+	}
+	return "LIMIT " + l + " OFFSET " + strconv.Itoa(offset)
+}
+
+func (Dialect) Returning(pk string) string {
+	return ""
+}
+
+// TranslateError converts the error numbers of the MySQL server to repository errors
+func (Dialect) TranslateError(err error) error {
+	var mysqlErr *driver.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	switch mysqlErr.Number {
+	// duplicate entry
+	case 1062, 1586:
+		return repository.NewError(repository.ErrConflict, err)
+	// foreign key, not null, data too long, no default value, incorrect value, check constraint
+	case 1451, 1452, 1048, 1406, 1364, 1292, 1366, 3819:
+		return repository.NewError(repository.ErrConstraint, err)
+	// unknown column, unknown table, syntax error
+	case 1054, 1146, 1064:
+		return repository.NewError(repository.ErrInvalidQuery, err)
+	}
+	return err
+}
+
+// Words replaces the runs of characters that are not letters or digits with a space
+func (Dialect) Words(column string) string {
+	return "REGEXP_REPLACE(LOWER(" + column + "), '[^[:alnum:]]+', ' ')"
+}
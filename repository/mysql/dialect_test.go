@@ -0,0 +1,31 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialect(t *testing.T) {
+	d := Dialect{}
+	assert.Equal(t, "?", d.Placeholder(3))
+	assert.Equal(t, "`users`", d.Quote("users"))
+	assert.Equal(t, "", d.Returning("id"))
+	assert.Equal(t, "", d.Limit(0, 0))
+	assert.Equal(t, "LIMIT 10", d.Limit(10, 0))
+	assert.Equal(t, "LIMIT 10 OFFSET 20", d.Limit(10, 20))
+	assert.Equal(t, "LIMIT 18446744073709551615 OFFSET 20", d.Limit(0, 20))
+}
+
+func TestTranslateError(t *testing.T) {
+	d := Dialect{}
+	assert.ErrorIs(t, d.TranslateError(&driver.MySQLError{Number: 1062}), repository.ErrConflict)
+	assert.ErrorIs(t, d.TranslateError(&driver.MySQLError{Number: 1452}), repository.ErrConstraint)
+	assert.ErrorIs(t, d.TranslateError(&driver.MySQLError{Number: 1054}), repository.ErrInvalidQuery)
+
+	err := errors.New("connection refused")
+	assert.Equal(t, err, d.TranslateError(err))
+}
@@ -0,0 +1,213 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	"github.com/franciscoescher/gosimplerest/resource"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Conventional column names detected by ResourceFromDatabase
+const (
+	createdAtColumn = "created_at"
+	updatedAtColumn = "updated_at"
+	deletedAtColumn = "deleted_at"
+)
+
+// column is a column of a table, as read from INFORMATION_SCHEMA.COLUMNS
+type column struct {
+	Name     string
+	Nullable bool
+	DataType string
+	// ColumnType is the full type, such as tinyint(1)
+	ColumnType string
+	MaxLength  null.Int
+	Default    null.String
+	Key        string
+	Extra      string
+}
+
+const columnsQuery = `SELECT COLUMN_NAME, IS_NULLABLE, DATA_TYPE, COLUMN_TYPE, CHARACTER_MAXIMUM_LENGTH, COLUMN_DEFAULT, COLUMN_KEY, EXTRA
+FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`
+
+const tablesQuery = `SELECT TABLE_NAME
+FROM INFORMATION_SCHEMA.TABLES
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'
+ORDER BY TABLE_NAME`
+
+/*
+ResourceFromDatabase reads the schema of a table of the current MySQL database and returns its resource
+
+The INFORMATION_SCHEMA of the table is used to get:
+  - the fields, one for each column
+  - the primary key, which must be a single column, and if it is auto incremental
+  - the types of the fields, from the types of the columns, where tinyint(1) is a boolean
+  - the validation rules: required for not null columns without a default value
+    and max for the length of character columns
+  - the created at, updated at and soft delete fields, if the table
+    has the created_at, updated_at and deleted_at columns
+
+The omit route flags, OverwriteTableName and GeneratePrimaryKeyFunc are not populated by this function
+*/
+func ResourceFromDatabase(ctx context.Context, db *sql.DB, table string) (resource.Resource, error) {
+	rows, err := db.QueryContext(ctx, columnsQuery, table)
+	if err != nil {
+		return resource.Resource{}, err
+	}
+	defer rows.Close()
+
+	columns := make([]column, 0)
+	for rows.Next() {
+		var c column
+		var nullable string
+		var maxLength sql.NullInt64
+		err = rows.Scan(&c.Name, &nullable, &c.DataType, &c.ColumnType, &maxLength, &c.Default, &c.Key, &c.Extra)
+		if err != nil {
+			return resource.Resource{}, err
+		}
+		c.Nullable = nullable == "YES"
+		c.MaxLength = null.Int{NullInt64: maxLength}
+		columns = append(columns, c)
+	}
+	err = rows.Err()
+	if err != nil {
+		return resource.Resource{}, err
+	}
+	if len(columns) == 0 {
+		return resource.Resource{}, fmt.Errorf("table %s not found", table)
+	}
+
+	return fromColumns(table, columns)
+}
+
+// ResourcesFromDatabase returns a resource for each table of the current MySQL database,
+// as read by ResourceFromDatabase. Tables without a single column primary key are skipped.
+func ResourcesFromDatabase(ctx context.Context, db *sql.DB) ([]resource.Resource, error) {
+	tables, err := sqlrepo.QueryStrings(ctx, db, tablesQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]resource.Resource, 0, len(tables))
+	for _, table := range tables {
+		r, err := ResourceFromDatabase(ctx, db, table)
+		if errors.Is(err, errNoPrimaryKey) || errors.Is(err, errCompositePrimaryKey) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+var (
+	errNoPrimaryKey        = errors.New("table has no primary key")
+	errCompositePrimaryKey = errors.New("table has a composite primary key")
+)
+
+// fromColumns returns the resource of a table with the given columns
+func fromColumns(table string, columns []column) (resource.Resource, error) {
+	b := resource.Resource{Name: table}
+	for _, c := range columns {
+		if c.Key != "PRI" {
+			continue
+		}
+		if b.PrimaryKey != "" {
+			return resource.Resource{}, fmt.Errorf("%s: %w", table, errCompositePrimaryKey)
+		}
+		b.PrimaryKey = c.Name
+		b.AutoIncrementalPK = strings.Contains(strings.ToLower(c.Extra), "auto_increment")
+	}
+	if b.PrimaryKey == "" {
+		return resource.Resource{}, fmt.Errorf("%s: %w", table, errNoPrimaryKey)
+	}
+
+	b.Fields = make(map[string]resource.Field, len(columns))
+	for _, c := range columns {
+		switch c.Name {
+		case createdAtColumn:
+			b.CreatedAtField = null.StringFrom(c.Name)
+		case updatedAtColumn:
+			b.UpdatedAtField = null.StringFrom(c.Name)
+		case deletedAtColumn:
+			b.SoftDeleteField = null.StringFrom(c.Name)
+		}
+		b.Fields[c.Name] = resource.Field{Type: columnType(c), Validator: columnValidator(b.PrimaryKey, c)}
+	}
+	return b, nil
+}
+
+// columnValidator returns the validation rules of a column
+func columnValidator(primaryKey string, c column) string {
+	rules := make([]string, 0, 2)
+
+	// values of the primary key and timestamps are generated by the handlers
+	generated := c.Name == primaryKey || c.Name == createdAtColumn || c.Name == updatedAtColumn || c.Name == deletedAtColumn
+	character := c.MaxLength.Valid && isCharacterType(c.DataType)
+
+	// required fails for the zero value of numbers and booleans, so it is only used
+	// for types where the zero value is not a valid input
+	if !c.Nullable && !c.Default.Valid && !generated && !isNumericType(c.DataType) {
+		rules = append(rules, "required")
+	} else if character {
+		rules = append(rules, "omitempty")
+	}
+	if character {
+		rules = append(rules, "max="+strconv.FormatInt(c.MaxLength.Int64, 10))
+	}
+	return strings.Join(rules, ",")
+}
+
+// columnType returns the type of the field of a column
+func columnType(c column) resource.Type {
+	switch strings.ToLower(c.DataType) {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return resource.TypeString
+	case "tinyint":
+		if strings.HasPrefix(strings.ToLower(c.ColumnType), "tinyint(1)") {
+			return resource.TypeBool
+		}
+		return resource.TypeInt
+	case "smallint", "mediumint", "int", "integer", "bigint", "year":
+		return resource.TypeInt
+	case "float", "double", "real":
+		return resource.TypeFloat
+	case "decimal", "numeric":
+		return resource.TypeDecimal
+	case "datetime", "timestamp":
+		return resource.TypeTime
+	case "date":
+		return resource.TypeDate
+	case "json":
+		return resource.TypeJSON
+	}
+	return resource.TypeAny
+}
+
+// isCharacterType returns true for the MySQL types with a character length
+func isCharacterType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return true
+	}
+	return false
+}
+
+// isNumericType returns true for the MySQL numeric and boolean types
+func isNumericType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "decimal", "numeric", "float", "double", "bit":
+		return true
+	}
+	return false
+}
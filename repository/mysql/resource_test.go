@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	null "gopkg.in/guregu/null.v3"
+)
+
+func TestFromColumns(t *testing.T) {
+	// users table of the examples, with a not null column added
+	columns := []column{
+		{Name: "uuid", DataType: "varchar", MaxLength: null.IntFrom(191), Key: "PRI"},
+		{Name: "created_at", DataType: "datetime", Nullable: true},
+		{Name: "updated_at", DataType: "datetime", Nullable: true},
+		{Name: "deleted_at", DataType: "datetime", Nullable: true},
+		{Name: "first_name", DataType: "varchar", MaxLength: null.IntFrom(255), Nullable: true},
+		{Name: "email", DataType: "varchar", MaxLength: null.IntFrom(100)},
+		{Name: "phone", DataType: "longtext", MaxLength: null.IntFrom(4294967295), Nullable: true},
+		{Name: "archived", DataType: "tinyint", ColumnType: "tinyint(1)", Default: null.StringFrom("0")},
+		{Name: "year", DataType: "smallint"},
+		{Name: "birth_date", DataType: "date"},
+	}
+
+	r, err := fromColumns("users", columns)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "users", r.Name)
+	assert.Equal(t, "uuid", r.PrimaryKey)
+	assert.False(t, r.AutoIncrementalPK)
+	assert.Equal(t, null.StringFrom("created_at"), r.CreatedAtField)
+	assert.Equal(t, null.StringFrom("updated_at"), r.UpdatedAtField)
+	assert.Equal(t, null.StringFrom("deleted_at"), r.SoftDeleteField)
+	assert.Equal(t, map[string]resource.Field{
+		"uuid":       {Type: resource.TypeString, Validator: "omitempty,max=191"},
+		"created_at": {Type: resource.TypeTime},
+		"updated_at": {Type: resource.TypeTime},
+		"deleted_at": {Type: resource.TypeTime},
+		"first_name": {Type: resource.TypeString, Validator: "omitempty,max=255"},
+		"email":      {Type: resource.TypeString, Validator: "required,max=100"},
+		"phone":      {Type: resource.TypeString, Validator: "omitempty,max=4294967295"},
+		"archived":   {Type: resource.TypeBool},
+		"year":       {Type: resource.TypeInt},
+		"birth_date": {Type: resource.TypeDate, Validator: "required"},
+	}, r.Fields)
+}
+
+func TestFromColumnsPrimaryKey(t *testing.T) {
+	r, err := fromColumns("events", []column{
+		{Name: "id", DataType: "bigint", Key: "PRI", Extra: "auto_increment"},
+		{Name: "name", DataType: "varchar", MaxLength: null.IntFrom(255), Nullable: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "id", r.PrimaryKey)
+	assert.True(t, r.AutoIncrementalPK)
+	assert.False(t, r.SoftDeleteField.Valid)
+
+	_, err = fromColumns("logs", []column{{Name: "message", DataType: "text"}})
+	assert.ErrorIs(t, err, errNoPrimaryKey)
+
+	_, err = fromColumns("user_roles", []column{
+		{Name: "user_id", DataType: "varchar", Key: "PRI"},
+		{Name: "role_id", DataType: "varchar", Key: "PRI"},
+	})
+	assert.ErrorIs(t, err, errCompositePrimaryKey)
+}
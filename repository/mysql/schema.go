@@ -0,0 +1,102 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Compile-time check that Dialect implements the SchemaDialect and FullTextDialect interfaces
+var _ sqlrepo.SchemaDialect = Dialect{}
+var _ sqlrepo.FullTextDialect = Dialect{}
+
+// ColumnType uses varchar(191) for keys, the longest that can be indexed with utf8mb4
+func (Dialect) ColumnType(t resource.Type, key bool) string {
+	switch t {
+	case resource.TypeInt:
+		return "bigint"
+	case resource.TypeFloat:
+		return "double"
+	case resource.TypeBool:
+		return "tinyint(1)"
+	case resource.TypeTime:
+		return "datetime(3)"
+	case resource.TypeDate:
+		return "date"
+	case resource.TypeDecimal:
+		return "decimal(19,4)"
+	case resource.TypeJSON:
+		return "json"
+	case resource.TypeUUID:
+		return "char(36)"
+	}
+	if key {
+		return "varchar(191)"
+	}
+	return "varchar(255)"
+}
+
+func (Dialect) AutoIncrementColumn() string {
+	return "bigint NOT NULL AUTO_INCREMENT"
+}
+
+func (Dialect) Columns(ctx context.Context, db *sql.DB, table string) ([]sqlrepo.Column, error) {
+	return sqlrepo.QueryColumns(ctx, db, `SELECT COLUMN_NAME, COLUMN_KEY = 'PRI'
+FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`, table)
+}
+
+func (Dialect) Indexes(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	return sqlrepo.QueryStrings(ctx, db, `SELECT DISTINCT INDEX_NAME
+FROM INFORMATION_SCHEMA.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, table)
+}
+
+func (d Dialect) CreateFullTextIndex(name, table string, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = d.Quote(c)
+	}
+	return "CREATE FULLTEXT INDEX " + d.Quote(name) + " ON " + d.Quote(table) + " (" + strings.Join(quoted, ",") + ")"
+}
+
+func (Dialect) IndexColumns(ctx context.Context, db sqlrepo.Querier, table, index string) ([]string, error) {
+	return sqlrepo.QueryStrings(ctx, db, `SELECT COLUMN_NAME
+FROM INFORMATION_SCHEMA.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ? AND INDEX_TYPE = 'FULLTEXT'
+ORDER BY SEQ_IN_INDEX`, table, index)
+}
+
+// Match uses the boolean mode, so every term is required
+func (Dialect) Match(columns, placeholder string) string {
+	return "MATCH (" + columns + ") AGAINST (" + placeholder + " IN BOOLEAN MODE)"
+}
+
+// MatchTerms requires each term, as a prefix of the words of the index
+func (Dialect) MatchTerms(terms []string) string {
+	required := make([]string, len(terms))
+	for i, term := range terms {
+		required[i] = "+" + term + "*"
+	}
+	return strings.Join(required, " ")
+}
+
+// NewMigrator returns a new Migrator for a MySQL database
+func NewMigrator(db *sql.DB) *sqlrepo.Migrator {
+	return sqlrepo.NewMigrator(db, Dialect{})
+}
+
+// AutoMigrate creates the missing tables of the resources and adds their missing columns and indexes
+func AutoMigrate(db *sql.DB, resources ...*resource.Resource) error {
+	return NewMigrator(db).AutoMigrate(context.Background(), resources...)
+}
+
+// CheckSchema compares the resources with their tables, returning the drifts found
+func CheckSchema(db *sql.DB, resources ...*resource.Resource) (repository.SchemaReport, error) {
+	return NewMigrator(db).CheckSchema(context.Background(), resources...)
+}
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTable(t *testing.T) {
+	orders := resource.Resource{
+		Name:              "orders",
+		PrimaryKey:        "id",
+		AutoIncrementalPK: true,
+		Fields: map[string]resource.Field{
+			"id":       {Type: resource.TypeInt},
+			"user_id":  {Type: resource.TypeUUID, Index: true},
+			"code":     {Type: resource.TypeString, Index: true},
+			"total":    {Type: resource.TypeDecimal},
+			"paid":     {Type: resource.TypeBool},
+			"paid_at":  {Type: resource.TypeTime},
+			"metadata": {Type: resource.TypeJSON},
+			"notes":    {},
+		},
+	}
+	assert.Equal(t, []string{
+		"CREATE TABLE `orders` (`id` bigint NOT NULL AUTO_INCREMENT, `code` varchar(191), `metadata` json, `notes` varchar(255), `paid` tinyint(1), `paid_at` datetime(3), `total` decimal(19,4), `user_id` char(36), PRIMARY KEY (`id`))",
+		"CREATE INDEX `idx_orders_code` ON `orders` (`code`)",
+		"CREATE INDEX `idx_orders_user_id` ON `orders` (`user_id`)",
+	}, sqlrepo.CreateTable(Dialect{}, &orders))
+
+	users := resource.Resource{
+		Name:       "users",
+		PrimaryKey: "uuid",
+		Fields:     map[string]resource.Field{"uuid": {}, "first_name": {}},
+	}
+	assert.Equal(t, []string{
+		"CREATE TABLE `users` (`uuid` varchar(191) NOT NULL, `first_name` varchar(255), PRIMARY KEY (`uuid`))",
+	}, sqlrepo.CreateTable(Dialect{}, &users))
+
+	users.Fields = map[string]resource.Field{"uuid": {}, "first_name": {FullText: true}, "last_name": {FullText: true}}
+	assert.Equal(t, []string{
+		"CREATE TABLE `users` (`uuid` varchar(191) NOT NULL, `first_name` varchar(255), `last_name` varchar(255), PRIMARY KEY (`uuid`))",
+		"CREATE FULLTEXT INDEX `ft_users` ON `users` (`first_name`,`last_name`)",
+	}, sqlrepo.CreateTable(Dialect{}, &users))
+}
+
+func TestMatch(t *testing.T) {
+	d := Dialect{}
+	assert.Equal(t, "MATCH (`first_name`,`last_name`) AGAINST (? IN BOOLEAN MODE)", d.Match("`first_name`,`last_name`", "?"))
+	assert.Equal(t, "+ana* +silva*", d.MatchTerms([]string{"ana", "silva"}))
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+)
+
+// Dialect is the PostgreSQL syntax used by the sqlrepo query builder
+type Dialect struct{}
+
+// Compile-time check that Dialect implements the Dialect and TextDialect interfaces
+var _ sqlrepo.Dialect = Dialect{}
+var _ sqlrepo.TextDialect = Dialect{}
+
+func (Dialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (Dialect) Quote(identifier string) string {
+	return sqlrepo.QuoteIdentifier(identifier, `"`)
+}
+
+func (Dialect) CurrentTimestamp() string {
+	return "now()"
+}
+
+func (Dialect) Limit(limit, offset int) string {
+	clauses := make([]string, 0, 2)
+	if limit > 0 {
+		clauses = append(clauses, "LIMIT "+strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		clauses = append(clauses, "OFFSET "+strconv.Itoa(offset))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// Returning reads the generated key with RETURNING, since postgres has no LastInsertId
+func (d Dialect) Returning(pk string) string {
+	return "RETURNING " + d.Quote(pk)
+}
+
+// sqlStateError is implemented by the errors of the postgres drivers,
+// such as lib/pq and pgx, so the dialect does not depend on one of them
+type sqlStateError interface {
+	SQLState() string
+}
+
+// TranslateError converts the SQLSTATE codes of the PostgreSQL server to repository errors
+func (Dialect) TranslateError(err error) error {
+	var stateErr sqlStateError
+	if !errors.As(err, &stateErr) {
+		return err
+	}
+	state := stateErr.SQLState()
+	switch {
+	// unique violation
+	case state == "23505":
+		return repository.NewError(repository.ErrConflict, err)
+	// integrity constraint violations, such as foreign key, not null and check
+	case strings.HasPrefix(state, "23"):
+		return repository.NewError(repository.ErrConstraint, err)
+	// string too long, invalid text representation, invalid datetime format, numeric out of range
+	case state == "22001", state == "22P02", state == "22007", state == "22003":
+		return repository.NewError(repository.ErrConstraint, err)
+	// undefined column, undefined table, syntax error
+	case state == "42703", state == "42P01", state == "42601":
+		return repository.NewError(repository.ErrInvalidQuery, err)
+	}
+	return err
+}
+
+// Words replaces the runs of characters that are not letters or digits with a space
+func (Dialect) Words(column string) string {
+	return "regexp_replace(LOWER(" + column + "), '[^[:alnum:]]+', ' ', 'g')"
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialect(t *testing.T) {
+	d := Dialect{}
+	assert.Equal(t, "$3", d.Placeholder(3))
+	assert.Equal(t, `"users"`, d.Quote("users"))
+	assert.Equal(t, `RETURNING "id"`, d.Returning("id"))
+	assert.Equal(t, "", d.Limit(0, 0))
+	assert.Equal(t, "LIMIT 10", d.Limit(10, 0))
+	assert.Equal(t, "LIMIT 10 OFFSET 20", d.Limit(10, 20))
+	assert.Equal(t, "OFFSET 20", d.Limit(0, 20))
+}
+
+// stateError mimics the errors of the postgres drivers
+type stateError string
+
+func (e stateError) Error() string    { return "pq: " + string(e) }
+func (e stateError) SQLState() string { return string(e) }
+
+func TestTranslateError(t *testing.T) {
+	d := Dialect{}
+	assert.ErrorIs(t, d.TranslateError(stateError("23505")), repository.ErrConflict)
+	assert.ErrorIs(t, d.TranslateError(stateError("23503")), repository.ErrConstraint)
+	assert.ErrorIs(t, d.TranslateError(stateError("42703")), repository.ErrInvalidQuery)
+
+	err := errors.New("connection refused")
+	assert.Equal(t, err, d.TranslateError(err))
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+)
+
+// Repository is the implementation of the RepositoryInterface for PostgreSQL database.
+type Repository struct {
+	sqlrepo.Repository
+}
+
+// NewRepository returns a new PostgreSQL Repository
+func NewRepository(db *sql.DB) Repository {
+	return Repository{Repository: sqlrepo.NewRepository(db, Dialect{})}
+}
+
+// Compile-time check that Repository implements the Repository, Transactional, ReadTransactional and SchemaChecker interfaces
+var _ repository.RepositoryInterface = (*Repository)(nil)
+var _ repository.Transactional = (*Repository)(nil)
+var _ repository.ReadTransactional = (*Repository)(nil)
+var _ repository.SchemaChecker = (*Repository)(nil)
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Compile-time check that Dialect implements the SchemaDialect interface
+var _ sqlrepo.SchemaDialect = Dialect{}
+
+func (Dialect) ColumnType(t resource.Type, key bool) string {
+	switch t {
+	case resource.TypeInt:
+		return "bigint"
+	case resource.TypeFloat:
+		return "double precision"
+	case resource.TypeBool:
+		return "boolean"
+	case resource.TypeTime:
+		return "timestamptz"
+	case resource.TypeDate:
+		return "date"
+	case resource.TypeDecimal:
+		return "numeric"
+	case resource.TypeJSON:
+		return "jsonb"
+	case resource.TypeUUID:
+		return "uuid"
+	}
+	return "text"
+}
+
+func (Dialect) AutoIncrementColumn() string {
+	return "bigint GENERATED BY DEFAULT AS IDENTITY"
+}
+
+// Columns reads the columns from pg_attribute, resolving the table
+// with the search path as the statements of the repository do
+func (d Dialect) Columns(ctx context.Context, db *sql.DB, table string) ([]sqlrepo.Column, error) {
+	return sqlrepo.QueryColumns(ctx, db, `SELECT a.attname, i.indisprimary IS NOT NULL
+FROM pg_attribute a
+LEFT JOIN pg_index i ON i.indrelid = a.attrelid AND i.indisprimary AND a.attnum = ANY(i.indkey)
+WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`, d.Quote(table))
+}
+
+func (d Dialect) Indexes(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	return sqlrepo.QueryStrings(ctx, db, `SELECT c.relname
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indexrelid
+WHERE i.indrelid = to_regclass($1)`, d.Quote(table))
+}
+
+// NewMigrator returns a new Migrator for a PostgreSQL database
+func NewMigrator(db *sql.DB) *sqlrepo.Migrator {
+	return sqlrepo.NewMigrator(db, Dialect{})
+}
+
+// AutoMigrate creates the missing tables of the resources and adds their missing columns and indexes
+func AutoMigrate(db *sql.DB, resources ...*resource.Resource) error {
+	return NewMigrator(db).AutoMigrate(context.Background(), resources...)
+}
+
+// CheckSchema compares the resources with their tables, returning the drifts found
+func CheckSchema(db *sql.DB, resources ...*resource.Resource) (repository.SchemaReport, error) {
+	return NewMigrator(db).CheckSchema(context.Background(), resources...)
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTable(t *testing.T) {
+	orders := resource.Resource{
+		Name:              "orders",
+		PrimaryKey:        "id",
+		AutoIncrementalPK: true,
+		Fields: map[string]resource.Field{
+			"id":       {Type: resource.TypeInt},
+			"user_id":  {Type: resource.TypeUUID, Index: true},
+			"total":    {Type: resource.TypeDecimal},
+			"paid":     {Type: resource.TypeBool},
+			"paid_at":  {Type: resource.TypeTime},
+			"metadata": {Type: resource.TypeJSON},
+			"notes":    {},
+		},
+	}
+	assert.Equal(t, []string{
+		`CREATE TABLE "orders" ("id" bigint GENERATED BY DEFAULT AS IDENTITY, "metadata" jsonb, "notes" text, "paid" boolean, "paid_at" timestamptz, "total" numeric, "user_id" uuid, PRIMARY KEY ("id"))`,
+		`CREATE INDEX "idx_orders_user_id" ON "orders" ("user_id")`,
+	}, sqlrepo.CreateTable(Dialect{}, &orders))
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Query is a search of the rows of a resource
+type Query struct {
+	// Where is a map of field names and values, parsed to the types of the fields.
+	// Multiple values for the same field are ORed and different fields are ANDed.
+	Where map[string][]any
+	// Filters compare the values of the fields, and are ANDed with each other and with Where
+	Filters []Filter
+	// Expr is a boolean expression of filters, ANDed with Where and Filters
+	Expr Expr
+	// Text is a full-text search, matched against the full-text fields of the resource, see TextTerms
+	Text string
+	// IncludeDeleted includes the soft deleted rows, which are excluded by default
+	IncludeDeleted bool
+	// Fields are the fields returned in the rows, see Columns
+	Fields []string
+	// Sort is the order of the rows, validated against the resource.
+	// Rows are always ordered by the primary key after it, so the order is stable.
+	Sort []Sort
+	// Limit is the maximum number of rows returned, if 0, all rows are returned
+	Limit int
+	// Offset is the number of rows skipped
+	Offset int
+	// Cursor returns only the rows after, or before, a given row,
+	// so pages can be read without the cost of skipping rows
+	Cursor *Cursor
+}
+
+// Filter compares the values of a field with the values of the filter.
+// Null values of the field only match the null operator.
+type Filter struct {
+	Field string
+	Op    resource.Operator
+	// Values are parsed to the type of the field. The in and nin operators take
+	// one or more values, null takes a bool, and the others take a single value.
+	Values []any
+}
+
+// ValidateFilters returns an error if a filter is not for a field of the resource,
+// has an unknown operator or has the wrong number of values
+func ValidateFilters(b *resource.Resource, filters []Filter) error {
+	for _, f := range filters {
+		if !b.HasField(f.Field) {
+			return NewError(ErrInvalidQuery, fmt.Errorf("field %s does not exist", f.Field))
+		}
+		if !f.Op.Valid() {
+			return NewError(ErrInvalidQuery, fmt.Errorf("invalid operator %s", f.Op))
+		}
+		switch f.Op {
+		case resource.OpIn, resource.OpNin:
+			if len(f.Values) == 0 {
+				return NewError(ErrInvalidQuery, fmt.Errorf("operator %s of field %s without values", f.Op, f.Field))
+			}
+		case resource.OpNull:
+			if len(f.Values) != 1 {
+				return NewError(ErrInvalidQuery, fmt.Errorf("operator %s of field %s takes a bool", f.Op, f.Field))
+			}
+			if _, ok := f.Values[0].(bool); !ok {
+				return NewError(ErrInvalidQuery, fmt.Errorf("operator %s of field %s takes a bool", f.Op, f.Field))
+			}
+		default:
+			if len(f.Values) != 1 || f.Values[0] == nil {
+				return NewError(ErrInvalidQuery, fmt.Errorf("operator %s of field %s takes a single value", f.Op, f.Field))
+			}
+		}
+	}
+	return nil
+}
+
+// Sort is a field the rows are ordered by.
+// Null values are lower than any other value.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Cursor is the position of a row in the results of a search,
+// used for keyset pagination
+type Cursor struct {
+	// Key is the primary key of the row, parsed to the type of the field
+	Key any
+	// Values are the values of the row for each field of the sort of the query,
+	// parsed to the types of the fields
+	Values []any
+	// Before selects the rows before the position instead of after it.
+	// They are still returned in ascending order, and the limit and offset
+	// are counted from the position, so the closest rows are returned.
+	Before bool
+}
+
+// Order returns the fields the rows of the query are ordered by:
+// its sort, followed by the primary key if it is not sorted already
+func (q Query) Order(pk string) []Sort {
+	order := make([]Sort, 0, len(q.Sort)+1)
+	for _, s := range q.Sort {
+		if s.Field == pk {
+			return append(order, s)
+		}
+		order = append(order, s)
+	}
+	return append(order, Sort{Field: pk})
+}
+
+// Position returns the values of the cursor of the query for each field of Order
+func (q Query) Position(pk string) ([]any, error) {
+	if len(q.Cursor.Values) != len(q.Sort) {
+		return nil, fmt.Errorf("cursor has %d values for %d sort fields", len(q.Cursor.Values), len(q.Sort))
+	}
+	order := q.Order(pk)
+	position := make([]any, len(order))
+	for i, s := range order {
+		if s.Field == pk {
+			position[i] = q.Cursor.Key
+		} else {
+			position[i] = q.Cursor.Values[i]
+		}
+	}
+	return position, nil
+}
+
+// FindOptions are the options of a Find
+type FindOptions struct {
+	// IncludeDeleted finds the row even if it is soft deleted
+	IncludeDeleted bool
+	// Fields are the fields returned in the row, see Columns
+	Fields []string
+}
+
+// Columns returns the fields selected by a projection, in alphabetical order:
+// all the fields of the resource if it is empty, or the given fields and the
+// primary key otherwise. Fields that are not in the resource are an error.
+func Columns(b *resource.Resource, fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return b.GetFieldNames(), nil
+	}
+	selected := map[string]bool{b.PrimaryKey: true}
+	for _, field := range fields {
+		if !b.HasField(field) {
+			return nil, NewError(ErrInvalidQuery, fmt.Errorf("field %s does not exist", field))
+		}
+		selected[field] = true
+	}
+	columns := make([]string, 0, len(selected))
+	for field := range selected {
+		columns = append(columns, field)
+	}
+	sort.Strings(columns)
+	return columns, nil
+}
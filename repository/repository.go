@@ -1,23 +1,81 @@
 package repository
 
-import "github.com/franciscoescher/gosimplerest/resource"
+import (
+	"context"
 
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// RepositoryInterface is the storage of the resources.
+// The context of every method is passed down to the underlying storage,
+// so a cancelled request or an expired deadline stops the operation.
+// The behaviour documented here is checked by the repositorytest package.
 type RepositoryInterface interface {
+	// Count returns the number of rows matching the query,
+	// ignoring its limit, offset and cursor
+	Count(ctx context.Context, b *resource.Resource, q Query) (int64, error)
 	// Delete deletes a row with the given primary key from the database
-	Delete(b *resource.Resource, id any) error
+	// if the resource has a soft delete field, the row is kept with the field set to the current time
+	// returns an error if the row does not exist or is already soft deleted
+	Delete(ctx context.Context, b *resource.Resource, id any) error
 	// Find returns a single row from the database, search by the primary key
 	// return 0 rows if not found, but no error
-	Find(b *resource.Resource, id any) (map[string]any, error)
+	// soft deleted rows are not found, unless opts.IncludeDeleted is set
+	Find(ctx context.Context, b *resource.Resource, id any, opts FindOptions) (map[string]any, error)
 	// Insert inserts a new row into the database
 	// returns pk only if auto incremental
-	Insert(b *resource.Resource, data map[string]any) (int64, error)
-	// Search searches for rows in the database using the query parameters
+	Insert(ctx context.Context, b *resource.Resource, data map[string]any) (int64, error)
+	// Purge deletes a soft deleted row from the database, for good
+	// returns an error if the row does not exist or is not soft deleted
+	Purge(ctx context.Context, b *resource.Resource, id any) error
+	// Restore clears the soft delete field of a soft deleted row
+	// returns an error if the row does not exist or is not soft deleted
+	Restore(ctx context.Context, b *resource.Resource, id any) error
+	// Search searches for rows in the database matching the query
 	// returns 0 rows if not found, but no error
-	// query is a map of field names and values
-	// multiple values for the same field are ORed
-	Search(b *resource.Resource, query map[string][]string) ([]map[string]any, error)
+	// soft deleted rows are excluded, unless q.IncludeDeleted is set
+	// rows are ordered by the primary key, and paginated by the limit, offset and cursor of the query
+	Search(ctx context.Context, b *resource.Resource, q Query) ([]map[string]any, error)
 	// Update updates a row in the database
 	// One of the fields must be the primary key or it will return an error
-	// Returns true if the a row was updated, false if not found
-	Update(b *resource.Resource, data map[string]any) (bool, error)
+	// Returns true if the a row was updated, false if not found or soft deleted
+	Update(ctx context.Context, b *resource.Resource, data map[string]any) (bool, error)
+}
+
+// Transactional is implemented by the repositories that can run
+// several operations atomically, in a single transaction.
+type Transactional interface {
+	// WithTx runs fn in a transaction, passing a repository bound to it.
+	// The transaction is committed if fn returns nil and rolled back otherwise.
+	// Calling WithTx on the repository passed to fn reuses the same transaction.
+	WithTx(ctx context.Context, fn func(tx RepositoryInterface) error) error
+}
+
+// WithTx runs fn in a transaction if the repository implements Transactional,
+// otherwise fn is called with the repository itself.
+func WithTx(ctx context.Context, r RepositoryInterface, fn func(tx RepositoryInterface) error) error {
+	t, ok := r.(Transactional)
+	if !ok {
+		return fn(r)
+	}
+	return t.WithTx(ctx, fn)
+}
+
+// ReadTransactional is implemented by the transactional repositories that can run several
+// reads in a read-only transaction, which does not block the other read-only transactions.
+type ReadTransactional interface {
+	// WithReadTx runs fn in a read-only transaction, passing a repository bound to it,
+	// whose writes fail. Calling WithReadTx or WithTx on the repository passed to fn
+	// reuses the same transaction, as does calling WithReadTx in a transaction.
+	WithReadTx(ctx context.Context, fn func(tx RepositoryInterface) error) error
+}
+
+// WithReadTx runs fn in a read-only transaction if the repository implements ReadTransactional,
+// in a transaction if it implements Transactional, otherwise fn is called with the repository itself.
+func WithReadTx(ctx context.Context, r RepositoryInterface, fn func(tx RepositoryInterface) error) error {
+	t, ok := r.(ReadTransactional)
+	if !ok {
+		return WithTx(ctx, r, fn)
+	}
+	return t.WithReadTx(ctx, fn)
 }
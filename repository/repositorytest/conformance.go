@@ -0,0 +1,852 @@
+// Package repositorytest provides a conformance suite for implementations of
+// repository.RepositoryInterface, so every backend behaves the same way behind the handlers.
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Factory returns a new, empty repository for each test of the suite.
+// Backends that need a schema must create the tables of the resources
+// returned by Resources before returning the repository.
+type Factory func(t *testing.T) repository.RepositoryInterface
+
+// UserResource has a string primary key and soft deletes
+var UserResource = resource.Resource{
+	Name:       "conformance_users",
+	PrimaryKey: "uuid",
+	Fields: map[string]resource.Field{
+		"uuid":       {Type: resource.TypeString},
+		"first_name": {Type: resource.TypeString, Index: true, Sortable: true, FullText: true},
+		"last_name":  {Type: resource.TypeString, Sortable: true, FullText: true},
+		"deleted_at": {Type: resource.TypeTime},
+	},
+	SoftDeleteField: null.NewString("deleted_at", true),
+}
+
+// NoteResource has a string primary key and hard deletes
+var NoteResource = resource.Resource{
+	Name:       "conformance_notes",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":   {Type: resource.TypeString},
+		"body": {Type: resource.TypeString},
+	},
+}
+
+// EventResource has an auto incremental primary key
+var EventResource = resource.Resource{
+	Name:              "conformance_events",
+	PrimaryKey:        "id",
+	AutoIncrementalPK: true,
+	Fields: map[string]resource.Field{
+		"id":   {Type: resource.TypeInt},
+		"name": {Type: resource.TypeString},
+	},
+}
+
+// OrderResource has typed fields
+var OrderResource = resource.Resource{
+	Name:       "conformance_orders",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":       {Type: resource.TypeInt},
+		"total":    {Type: resource.TypeDecimal, Aggregatable: true},
+		"paid":     {Type: resource.TypeBool, Aggregatable: true},
+		"due_date": {Type: resource.TypeDate, Aggregatable: true},
+		"paid_at":  {Type: resource.TypeTime},
+		"metadata": {Type: resource.TypeJSON},
+	},
+}
+
+// MembershipResource joins the users to groups, and has soft deletes
+var MembershipResource = resource.Resource{
+	Name:       "conformance_memberships",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":         {Type: resource.TypeInt},
+		"group_id":   {Type: resource.TypeInt},
+		"user_uuid":  {Type: resource.TypeString},
+		"deleted_at": {Type: resource.TypeTime},
+	},
+	SoftDeleteField: null.NewString("deleted_at", true),
+}
+
+// Resources returns the resources used by the suite.
+// The sql backends can create their tables with AutoMigrate.
+func Resources() []*resource.Resource {
+	return []*resource.Resource{&UserResource, &NoteResource, &EventResource, &OrderResource, &MembershipResource}
+}
+
+// RunConformance runs the conformance suite against the repositories returned by factory
+func RunConformance(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r repository.RepositoryInterface)
+	}{
+		{"InsertAndFind", testInsertAndFind},
+		{"FindNotFound", testFindNotFound},
+		{"InsertAutoIncrementalPK", testInsertAutoIncrementalPK},
+		{"InsertDuplicatedPK", testInsertDuplicatedPK},
+		{"SearchAndsFieldsAndOrsValues", testSearchAndsFieldsAndOrsValues},
+		{"SearchOrdersByPrimaryKey", testSearchOrdersByPrimaryKey},
+		{"SearchNoResults", testSearchNoResults},
+		{"Update", testUpdate},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"UpdateWithoutPrimaryKey", testUpdateWithoutPrimaryKey},
+		{"HardDelete", testHardDelete},
+		{"SoftDelete", testSoftDelete},
+		{"Restore", testRestore},
+		{"Purge", testPurge},
+		{"ResourcesAreIsolated", testResourcesAreIsolated},
+		{"Transactions", testTransactions},
+		{"ReadTransactions", testReadTransactions},
+		{"TypedValues", testTypedValues},
+		{"SearchPagination", testSearchPagination},
+		{"SearchSort", testSearchSort},
+		{"Fields", testFields},
+		{"Filters", testFilters},
+		{"FilterExpr", testFilterExpr},
+		{"Related", testRelated},
+		{"FullText", testFullText},
+		{"Aggregate", testAggregate},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, factory(t))
+		})
+	}
+}
+
+func insertUsers(t *testing.T, r repository.RepositoryInterface, users ...map[string]any) {
+	for _, u := range users {
+		_, err := r.Insert(context.Background(), &UserResource, u)
+		require.NoError(t, err)
+	}
+}
+
+func user(uuid, first, last string) map[string]any {
+	return map[string]any{"uuid": uuid, "first_name": first, "last_name": last, "deleted_at": nil}
+}
+
+func testInsertAndFind(t *testing.T, r repository.RepositoryInterface) {
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	row, err := r.Find(context.Background(), &UserResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "a", row["uuid"])
+	assert.Equal(t, "Fulano", row["first_name"])
+	assert.Equal(t, "Silva", row["last_name"])
+	assert.Nil(t, row["deleted_at"])
+}
+
+func testFindNotFound(t *testing.T, r repository.RepositoryInterface) {
+	row, err := r.Find(context.Background(), &UserResource, "missing", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.NotNil(t, row)
+	assert.Len(t, row, 0)
+}
+
+func testInsertAutoIncrementalPK(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	first, err := r.Insert(ctx, &EventResource, map[string]any{"name": "first"})
+	require.NoError(t, err)
+	second, err := r.Insert(ctx, &EventResource, map[string]any{"name": "second"})
+	require.NoError(t, err)
+	assert.Greater(t, second, first)
+
+	row, err := r.Find(ctx, &EventResource, second, repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "second", row["name"])
+	assert.EqualValues(t, second, row["id"])
+}
+
+func testInsertDuplicatedPK(t *testing.T, r repository.RepositoryInterface) {
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	_, err := r.Insert(context.Background(), &UserResource, user("a", "Ciclano", "Souza"))
+	assert.ErrorIs(t, err, repository.ErrConflict)
+
+	row, err := r.Find(context.Background(), &UserResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Fulano", row["first_name"])
+}
+
+func testSearchAndsFieldsAndOrsValues(t *testing.T, r repository.RepositoryInterface) {
+	insertUsers(t, r,
+		user("a", "Fulano", "Silva"),
+		user("b", "Fulano", "Souza"),
+		user("c", "Ciclano", "Silva"),
+		user("d", "Beltrano", "Silva"),
+	)
+
+	rows, err := r.Search(context.Background(), &UserResource, repository.Query{Where: map[string][]any{
+		"first_name": {"Fulano", "Ciclano"},
+		"last_name":  {"Silva"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, pks(rows, "uuid"))
+}
+
+func testSearchOrdersByPrimaryKey(t *testing.T, r repository.RepositoryInterface) {
+	insertUsers(t, r,
+		user("c", "Fulano", "Silva"),
+		user("a", "Fulano", "Silva"),
+		user("b", "Fulano", "Silva"),
+	)
+
+	rows, err := r.Search(context.Background(), &UserResource, repository.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, pks(rows, "uuid"))
+}
+
+func testSearchPagination(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	insertUsers(t, r,
+		user("a", "Fulano", "Silva"),
+		user("b", "Fulano", "Silva"),
+		user("c", "Fulano", "Silva"),
+		user("d", "Fulano", "Silva"),
+		user("e", "Ciclano", "Silva"),
+	)
+	require.NoError(t, r.Delete(ctx, &UserResource, "b"))
+	where := map[string][]any{"first_name": {"Fulano"}}
+
+	search := func(q repository.Query) []string {
+		q.Where = where
+		rows, err := r.Search(ctx, &UserResource, q)
+		require.NoError(t, err)
+		return pks(rows, "uuid")
+	}
+	assert.Equal(t, []string{"a", "c"}, search(repository.Query{Limit: 2}))
+	assert.Equal(t, []string{"c", "d"}, search(repository.Query{Limit: 2, Offset: 1}))
+	assert.Equal(t, []string{"d"}, search(repository.Query{Offset: 2}))
+	assert.Equal(t, []string{}, search(repository.Query{Limit: 2, Offset: 3}))
+
+	// keyset pagination, the cursor row does not need to match the query
+	assert.Equal(t, []string{"c", "d"}, search(repository.Query{Cursor: &repository.Cursor{Key: "a"}}))
+	assert.Equal(t, []string{"c"}, search(repository.Query{Limit: 1, Cursor: &repository.Cursor{Key: "b"}}))
+	assert.Equal(t, []string{"a", "c"}, search(repository.Query{Limit: 2, Cursor: &repository.Cursor{Key: "d", Before: true}}))
+	assert.Equal(t, []string{"c", "d"}, search(repository.Query{Limit: 2, Cursor: &repository.Cursor{Key: "z", Before: true}}))
+	assert.Equal(t, []string{"a"}, search(repository.Query{Limit: 2, Offset: 1, Cursor: &repository.Cursor{Key: "d", Before: true}}))
+	assert.Equal(t, []string{}, search(repository.Query{Cursor: &repository.Cursor{Key: "a", Before: true}}))
+
+	// the count ignores the pagination
+	count, err := r.Count(ctx, &UserResource, repository.Query{Where: where, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	count, err = r.Count(ctx, &UserResource, repository.Query{IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, count)
+}
+
+func testSearchSort(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	noLastName := user("b", "Ciclano", "")
+	noLastName["last_name"] = nil
+	insertUsers(t, r,
+		user("a", "Fulano", "Silva"),
+		noLastName,
+		user("c", "Fulano", "Souza"),
+		user("d", "Beltrano", "Silva"),
+		user("e", "Ciclano", "Silva"),
+	)
+
+	search := func(q repository.Query) []string {
+		rows, err := r.Search(ctx, &UserResource, q)
+		require.NoError(t, err)
+		return pks(rows, "uuid")
+	}
+	lastName := []repository.Sort{{Field: "last_name"}}
+	byName := []repository.Sort{{Field: "last_name", Desc: true}, {Field: "first_name"}}
+
+	// nulls come first, and ties are ordered by the primary key
+	assert.Equal(t, []string{"b", "a", "d", "e", "c"}, search(repository.Query{Sort: lastName}))
+	assert.Equal(t, []string{"c", "d", "e", "a", "b"}, search(repository.Query{Sort: byName}))
+	assert.Equal(t, []string{"a", "c", "b", "e", "d"}, search(repository.Query{Sort: []repository.Sort{{Field: "first_name", Desc: true}}}))
+	assert.Equal(t, []string{"e", "d", "c"}, search(repository.Query{Sort: []repository.Sort{{Field: "uuid", Desc: true}}, Limit: 3}))
+
+	// cursors are positions in the sorted rows
+	cursor := func(key string, before bool, values ...any) *repository.Cursor {
+		return &repository.Cursor{Key: key, Values: values, Before: before}
+	}
+	assert.Equal(t, []string{"a", "b"}, search(repository.Query{Sort: byName, Cursor: cursor("e", false, "Silva", "Ciclano")}))
+	assert.Equal(t, []string{"d", "e"}, search(repository.Query{Sort: byName, Limit: 2, Cursor: cursor("c", false, "Souza", "Fulano")}))
+	assert.Equal(t, []string{"d", "e"}, search(repository.Query{Sort: byName, Limit: 2, Cursor: cursor("a", true, "Silva", "Fulano")}))
+	assert.Equal(t, []string{}, search(repository.Query{Sort: byName, Cursor: cursor("b", false, nil, "Ciclano")}))
+	assert.Equal(t, []string{"a"}, search(repository.Query{Sort: byName, Limit: 1, Cursor: cursor("b", true, nil, "Ciclano")}))
+	assert.Equal(t, []string{"a", "d", "e", "c"}, search(repository.Query{Sort: lastName, Cursor: cursor("b", false, nil)}))
+	assert.Equal(t, []string{"b"}, search(repository.Query{Sort: lastName, Cursor: cursor("a", true, "Silva")}))
+
+	// a cursor without the values of the sort is invalid
+	_, err := r.Search(ctx, &UserResource, repository.Query{Sort: lastName, Cursor: cursor("a", false)})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+}
+
+func testFields(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	insertUsers(t, r, user("a", "Fulano", "Silva"), user("b", "Ciclano", "Souza"))
+
+	// the primary key is always returned
+	row, err := r.Find(ctx, &UserResource, "a", repository.FindOptions{Fields: []string{"first_name"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"uuid": "a", "first_name": "Fulano"}, row)
+
+	// rows can be sorted by fields that are not returned
+	rows, err := r.Search(ctx, &UserResource, repository.Query{
+		Fields: []string{"last_name"},
+		Sort:   []repository.Sort{{Field: "first_name"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"uuid": "b", "last_name": "Souza"}, {"uuid": "a", "last_name": "Silva"}}, rows)
+
+	_, err = r.Find(ctx, &UserResource, "a", repository.FindOptions{Fields: []string{"missing"}})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+	_, err = r.Search(ctx, &UserResource, repository.Query{Fields: []string{"missing"}})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+}
+
+func testSearchNoResults(t *testing.T, r repository.RepositoryInterface) {
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	rows, err := r.Search(context.Background(), &UserResource, repository.Query{Where: map[string][]any{"first_name": {"Nobody"}}})
+	require.NoError(t, err)
+	assert.NotNil(t, rows)
+	assert.Len(t, rows, 0)
+}
+
+func testUpdate(t *testing.T, r repository.RepositoryInterface) {
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	ok, err := r.Update(context.Background(), &UserResource, map[string]any{"uuid": "a", "first_name": "Ciclano"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	row, err := r.Find(context.Background(), &UserResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Ciclano", row["first_name"])
+	assert.Equal(t, "Silva", row["last_name"])
+}
+
+func testUpdateNotFound(t *testing.T, r repository.RepositoryInterface) {
+	ok, err := r.Update(context.Background(), &UserResource, map[string]any{"uuid": "missing", "first_name": "Ciclano"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testUpdateWithoutPrimaryKey(t *testing.T, r repository.RepositoryInterface) {
+	_, err := r.Update(context.Background(), &UserResource, map[string]any{"first_name": "Ciclano"})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+}
+
+func testHardDelete(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	_, err := r.Insert(ctx, &NoteResource, map[string]any{"id": "a", "body": "note"})
+	require.NoError(t, err)
+
+	require.NoError(t, r.Delete(ctx, &NoteResource, "a"))
+	row, err := r.Find(ctx, &NoteResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Len(t, row, 0)
+
+	assert.ErrorIs(t, r.Delete(ctx, &NoteResource, "a"), repository.ErrNotFound)
+}
+
+func testSoftDelete(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	insertUsers(t, r, user("a", "Fulano", "Silva"), user("b", "Ciclano", "Souza"))
+
+	require.NoError(t, r.Delete(ctx, &UserResource, "a"))
+
+	// soft deleted rows are hidden, unless they are included
+	row, err := r.Find(ctx, &UserResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Len(t, row, 0)
+	row, err = r.Find(ctx, &UserResource, "a", repository.FindOptions{IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Fulano", row["first_name"])
+	assert.NotNil(t, row["deleted_at"])
+
+	rows, err := r.Search(ctx, &UserResource, repository.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, pks(rows, "uuid"))
+	rows, err = r.Search(ctx, &UserResource, repository.Query{IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, pks(rows, "uuid"))
+
+	// and can not be updated or deleted again
+	ok, err := r.Update(ctx, &UserResource, map[string]any{"uuid": "a", "first_name": "Beltrano"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.ErrorIs(t, r.Delete(ctx, &UserResource, "a"), repository.ErrNotFound)
+	assert.ErrorIs(t, r.Delete(ctx, &UserResource, "missing"), repository.ErrNotFound)
+}
+
+func testRestore(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	// only soft deleted rows can be restored
+	assert.ErrorIs(t, r.Restore(ctx, &UserResource, "a"), repository.ErrNotFound)
+	assert.ErrorIs(t, r.Restore(ctx, &UserResource, "missing"), repository.ErrNotFound)
+
+	require.NoError(t, r.Delete(ctx, &UserResource, "a"))
+	require.NoError(t, r.Restore(ctx, &UserResource, "a"))
+	row, err := r.Find(ctx, &UserResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Fulano", row["first_name"])
+	assert.Nil(t, row["deleted_at"])
+
+	assert.ErrorIs(t, r.Restore(ctx, &NoteResource, "a"), repository.ErrInvalidQuery)
+}
+
+func testPurge(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	// only soft deleted rows can be purged
+	assert.ErrorIs(t, r.Purge(ctx, &UserResource, "a"), repository.ErrNotFound)
+
+	require.NoError(t, r.Delete(ctx, &UserResource, "a"))
+	require.NoError(t, r.Purge(ctx, &UserResource, "a"))
+	row, err := r.Find(ctx, &UserResource, "a", repository.FindOptions{IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Len(t, row, 0)
+	assert.ErrorIs(t, r.Purge(ctx, &UserResource, "a"), repository.ErrNotFound)
+
+	assert.ErrorIs(t, r.Purge(ctx, &NoteResource, "a"), repository.ErrInvalidQuery)
+}
+
+func testResourcesAreIsolated(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+	_, err := r.Insert(ctx, &NoteResource, map[string]any{"id": "a", "body": "note"})
+	require.NoError(t, err)
+
+	row, err := r.Find(ctx, &UserResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Fulano", row["first_name"])
+	row, err = r.Find(ctx, &NoteResource, "a", repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "note", row["body"])
+}
+
+func testTransactions(t *testing.T, r repository.RepositoryInterface) {
+	if _, ok := r.(repository.Transactional); !ok {
+		t.Skip("repository is not transactional")
+	}
+	ctx := context.Background()
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	errRollback := errors.New("rollback")
+	err := repository.WithTx(ctx, r, func(tx repository.RepositoryInterface) error {
+		insertUsers(t, tx, user("b", "Ciclano", "Souza"))
+		_, err := tx.Update(ctx, &UserResource, map[string]any{"uuid": "a", "first_name": "Beltrano"})
+		require.NoError(t, err)
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	rows, err := r.Search(ctx, &UserResource, repository.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, pks(rows, "uuid"))
+	assert.Equal(t, "Fulano", rows[0]["first_name"])
+
+	err = repository.WithTx(ctx, r, func(tx repository.RepositoryInterface) error {
+		insertUsers(t, tx, user("b", "Ciclano", "Souza"))
+		// reads in the transaction see its own writes
+		row, err := tx.Find(ctx, &UserResource, "b", repository.FindOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "Ciclano", row["first_name"])
+		return nil
+	})
+	require.NoError(t, err)
+	rows, err = r.Search(ctx, &UserResource, repository.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, pks(rows, "uuid"))
+}
+
+func testReadTransactions(t *testing.T, r repository.RepositoryInterface) {
+	if _, ok := r.(repository.ReadTransactional); !ok {
+		t.Skip("repository has no read-only transactions")
+	}
+	ctx := context.Background()
+	insertUsers(t, r, user("a", "Fulano", "Silva"))
+
+	err := repository.WithReadTx(ctx, r, func(tx repository.RepositoryInterface) error {
+		row, err := tx.Find(ctx, &UserResource, "a", repository.FindOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "Fulano", row["first_name"])
+		// writes fail, also in the transactions started from it
+		_, err = tx.Insert(ctx, &UserResource, user("b", "Ciclano", "Souza"))
+		assert.Error(t, err)
+		return repository.WithTx(ctx, tx, func(tx repository.RepositoryInterface) error {
+			_, err := tx.Update(ctx, &UserResource, map[string]any{"uuid": "a", "first_name": "Beltrano"})
+			assert.Error(t, err)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	rows, err := r.Search(ctx, &UserResource, repository.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, pks(rows, "uuid"))
+	assert.Equal(t, "Fulano", rows[0]["first_name"])
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = repository.WithReadTx(canceled, r, func(tx repository.RepositoryInterface) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func testTypedValues(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	paidAt := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	dueDate := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, id := range []int64{1, 2} {
+		_, err := r.Insert(ctx, &OrderResource, map[string]any{
+			"id":       id,
+			"total":    json.Number("10.10"),
+			"paid":     id == 1,
+			"due_date": dueDate,
+			"paid_at":  paidAt,
+			"metadata": json.RawMessage(`{"tags":["a"]}`),
+		})
+		require.NoError(t, err)
+	}
+
+	row, err := r.Find(ctx, &OrderResource, int64(1), repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), row["id"])
+	require.IsType(t, json.Number(""), row["total"])
+	total, _ := new(big.Rat).SetString(row["total"].(json.Number).String())
+	assert.Equal(t, "10.10", total.FloatString(2))
+	assert.Equal(t, true, row["paid"])
+	assert.Equal(t, "2023-05-01", row["due_date"])
+	require.IsType(t, time.Time{}, row["paid_at"])
+	assert.True(t, paidAt.Equal(row["paid_at"].(time.Time)), "paid_at is %v", row["paid_at"])
+	require.IsType(t, json.RawMessage{}, row["metadata"])
+	assert.JSONEq(t, `{"tags":["a"]}`, string(row["metadata"].(json.RawMessage)))
+
+	rows, err := r.Search(ctx, &OrderResource, repository.Query{Where: map[string][]any{"paid": {false}, "due_date": {dueDate}}})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(2), rows[0]["id"])
+	assert.Equal(t, false, rows[0]["paid"])
+}
+
+func testFilters(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	paidAt := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	orders := []map[string]any{
+		{"id": int64(1), "total": json.Number("10.50"), "paid": true, "due_date": time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), "paid_at": paidAt},
+		{"id": int64(2), "total": json.Number("20.00"), "paid": false, "due_date": time.Date(2023, 2, 10, 0, 0, 0, 0, time.UTC), "paid_at": nil},
+		{"id": int64(3), "total": json.Number("5.25"), "paid": false, "due_date": time.Date(2023, 3, 10, 0, 0, 0, 0, time.UTC), "paid_at": nil},
+	}
+	for _, o := range orders {
+		_, err := r.Insert(ctx, &OrderResource, o)
+		require.NoError(t, err)
+	}
+
+	search := func(filters ...repository.Filter) []int64 {
+		rows, err := r.Search(ctx, &OrderResource, repository.Query{Filters: filters})
+		require.NoError(t, err)
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			ids[i], _ = row["id"].(int64)
+		}
+		return ids
+	}
+	filter := func(field string, op resource.Operator, values ...any) repository.Filter {
+		return repository.Filter{Field: field, Op: op, Values: values}
+	}
+	feb := time.Date(2023, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []int64{1, 2}, search(filter("total", resource.OpGt, json.Number("6"))))
+	assert.Equal(t, []int64{1, 3}, search(filter("total", resource.OpLte, json.Number("10.50"))))
+	assert.Equal(t, []int64{2, 3}, search(filter("due_date", resource.OpGte, feb)))
+	assert.Equal(t, []int64{1}, search(filter("due_date", resource.OpLt, feb)))
+	assert.Equal(t, []int64{2}, search(filter("due_date", resource.OpEq, feb)))
+	assert.Equal(t, []int64{2, 3}, search(filter("paid", resource.OpNe, true)))
+	assert.Equal(t, []int64{1, 3}, search(filter("id", resource.OpIn, int64(1), int64(3))))
+	assert.Equal(t, []int64{2}, search(filter("id", resource.OpNin, int64(1), int64(3))))
+	assert.Equal(t, []int64{1}, search(filter("id", resource.OpNe, int64(2)), filter("total", resource.OpGt, json.Number("6"))))
+
+	// null values only match the null operator
+	assert.Equal(t, []int64{2, 3}, search(filter("paid_at", resource.OpNull, true)))
+	assert.Equal(t, []int64{1}, search(filter("paid_at", resource.OpNull, false)))
+	assert.Equal(t, []int64{1}, search(filter("paid_at", resource.OpGt, paidAt.Add(-time.Hour))))
+	assert.Equal(t, []int64{}, search(filter("paid_at", resource.OpNe, paidAt)))
+
+	// patterns ignore case
+	noLastName := user("b", "Ciclano", "")
+	noLastName["last_name"] = nil
+	insertUsers(t, r, user("a", "Fulano", "Silva"), noLastName, user("c", "fulana", "Souza"))
+	users := func(filters ...repository.Filter) []string {
+		rows, err := r.Search(ctx, &UserResource, repository.Query{Filters: filters})
+		require.NoError(t, err)
+		return pks(rows, "uuid")
+	}
+	assert.Equal(t, []string{"a", "c"}, users(filter("first_name", resource.OpLike, "FUL%")))
+	assert.Equal(t, []string{"b"}, users(filter("first_name", resource.OpLike, "_iclan_")))
+	assert.Equal(t, []string{"c"}, users(filter("last_name", resource.OpNe, "Silva")))
+
+	count, err := r.Count(ctx, &UserResource, repository.Query{Filters: []repository.Filter{filter("first_name", resource.OpLike, "%lan%")}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+
+	for _, f := range []repository.Filter{
+		filter("missing", resource.OpEq, "a"),
+		filter("first_name", "between", "a"),
+		filter("first_name", resource.OpIn),
+		filter("first_name", resource.OpGt, "a", "b"),
+		filter("first_name", resource.OpNull, "yes"),
+	} {
+		_, err = r.Search(ctx, &UserResource, repository.Query{Filters: []repository.Filter{f}})
+		assert.ErrorIs(t, err, repository.ErrInvalidQuery, "%v", f)
+	}
+}
+
+func testFilterExpr(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	noLastName := user("b", "Ciclano", "")
+	noLastName["last_name"] = nil
+	deleted := user("d", "Amanda", "Souza")
+	deleted["deleted_at"] = time.Now().UTC()
+	insertUsers(t, r, user("a", "Ana", "Silva"), noLastName, user("c", "Fulano", "Souza"), deleted)
+
+	users := func(e repository.Expr) []string {
+		rows, err := r.Search(ctx, &UserResource, repository.Query{Expr: e})
+		require.NoError(t, err)
+		return pks(rows, "uuid")
+	}
+	filter := func(field string, op resource.Operator, values ...any) repository.Filter {
+		return repository.Filter{Field: field, Op: op, Values: values}
+	}
+
+	// ors across fields, excluding the soft deleted rows
+	startsWithA := filter("first_name", resource.OpLike, "a%")
+	assert.Equal(t, []string{"a", "b"}, users(repository.Or{startsWithA, filter("last_name", resource.OpNull, true)}))
+	assert.Equal(t, []string{"c"}, users(repository.And{
+		filter("last_name", resource.OpEq, "Souza"),
+		repository.Or{filter("first_name", resource.OpEq, "Fulano"), startsWithA},
+	}))
+	assert.Equal(t, []string{"b", "c"}, users(repository.Not{Expr: startsWithA}))
+
+	// comparisons with null values are unknown, so neither they nor their negation match
+	silva := filter("last_name", resource.OpEq, "Silva")
+	assert.Equal(t, []string{"c"}, users(repository.Not{Expr: silva}))
+	assert.Equal(t, []string{"a", "c"}, users(repository.Or{silva, repository.Not{Expr: silva}}))
+	assert.Equal(t, []string{"b", "c"}, users(repository.Not{Expr: repository.And{silva, filter("last_name", resource.OpNull, false)}}))
+	assert.Equal(t, []string{"c"}, users(repository.Not{Expr: repository.Or{silva, filter("first_name", resource.OpEq, "Ana")}}))
+
+	count, err := r.Count(ctx, &UserResource, repository.Query{Expr: repository.Not{Expr: silva}, IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	for _, e := range []repository.Expr{
+		repository.And{},
+		repository.Or{filter("first_name", resource.OpEq, "Ana"), nil},
+		repository.Not{},
+		repository.Not{Expr: filter("missing", resource.OpEq, "a")},
+	} {
+		_, err = r.Search(ctx, &UserResource, repository.Query{Expr: e})
+		assert.ErrorIs(t, err, repository.ErrInvalidQuery, "%v", e)
+	}
+}
+
+func testRelated(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	deleted := user("d", "Amanda", "Souza")
+	deleted["deleted_at"] = time.Now().UTC()
+	insertUsers(t, r, user("a", "Ana", "Silva"), user("b", "Ciclano", "Lima"), user("c", "Fulano", "Souza"), deleted)
+	memberships := []map[string]any{
+		{"id": int64(1), "group_id": int64(1), "user_uuid": "a"},
+		{"id": int64(2), "group_id": int64(1), "user_uuid": "c"},
+		{"id": int64(3), "group_id": int64(1), "user_uuid": "d"},
+		{"id": int64(4), "group_id": int64(2), "user_uuid": "b"},
+		{"id": int64(5), "group_id": int64(1), "user_uuid": "b", "deleted_at": time.Now().UTC()},
+		{"id": int64(6), "group_id": int64(1), "user_uuid": nil},
+	}
+	for _, m := range memberships {
+		_, err := r.Insert(ctx, &MembershipResource, m)
+		require.NoError(t, err)
+	}
+
+	group := func(id int64) repository.Related {
+		return repository.Related{Field: "uuid", Resource: &MembershipResource, Key: "user_uuid", Where: map[string][]any{"group_id": {id}}}
+	}
+	users := func(e repository.Expr) []string {
+		rows, err := r.Search(ctx, &UserResource, repository.Query{Expr: e})
+		require.NoError(t, err)
+		return pks(rows, "uuid")
+	}
+	// soft deleted related rows are left out, and so are null keys, so the negation matches the other rows
+	assert.Equal(t, []string{"a", "c"}, users(group(1)))
+	assert.Equal(t, []string{"b"}, users(repository.Not{Expr: group(1)}))
+	assert.Equal(t, []string{}, users(group(3)))
+	assert.Equal(t, []string{"a", "b", "c"}, users(repository.Not{Expr: group(3)}))
+	assert.Equal(t, []string{"a", "b", "c"}, users(repository.Or{group(1), group(2)}))
+
+	count, err := r.Count(ctx, &UserResource, repository.Query{Expr: group(1), IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+
+	_, err = r.Search(ctx, &UserResource, repository.Query{Expr: repository.Related{Field: "uuid", Resource: &MembershipResource, Key: "missing"}})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+}
+
+func testFullText(t *testing.T, r repository.RepositoryInterface) {
+	ctx := context.Background()
+	noLastName := user("b", "Ciclano", "")
+	noLastName["last_name"] = nil
+	deleted := user("d", "Ana", "Silva")
+	deleted["deleted_at"] = time.Now().UTC()
+	insertUsers(t, r, user("a", "Ana Maria", "Silva"), noLastName, user("c", "Fulano", "Souza Lima"), deleted, user("e", "Mariana", "Costa"), user("f", "Mary-Jane", "Rocha/Pires"))
+
+	users := func(text string) []string {
+		rows, err := r.Search(ctx, &UserResource, repository.Query{Text: text})
+		require.NoError(t, err)
+		return pks(rows, "uuid")
+	}
+	// every term starts a word of one of the fields, ignoring case. The terms have at least
+	// 3 characters and are not stop words, since the MySQL FULLTEXT indexes ignore the other
+	// ones with their default settings, while the LIKE fallback matches them.
+	assert.Equal(t, []string{"a"}, users("ana"))
+	assert.Equal(t, []string{"a", "e"}, users("MARI"))
+	assert.Equal(t, []string{"a"}, users("silva ana"))
+	assert.Equal(t, []string{"c"}, users("fulano lima"))
+	assert.Equal(t, []string{}, users("ana costa"))
+	assert.Equal(t, []string{"a", "b", "c", "e", "f"}, users(""))
+	// words are also separated by punctuation, in the values and in the text
+	assert.Equal(t, []string{"f"}, users("jane"))
+	assert.Equal(t, []string{"f"}, users("pires mary-jane"))
+
+	count, err := r.Count(ctx, &UserResource, repository.Query{Text: "silva", IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	// resources without full-text fields can not be searched
+	_, err = r.Search(ctx, &NoteResource, repository.Query{Text: "ana"})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+}
+
+func testAggregate(t *testing.T, r repository.RepositoryInterface) {
+	aggregator, ok := r.(repository.Aggregator)
+	if !ok {
+		t.Skip("repository does not implement repository.Aggregator")
+	}
+	ctx := context.Background()
+	jan := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2023, 3, 10, 0, 0, 0, 0, time.UTC)
+	orders := []map[string]any{
+		{"id": int64(1), "total": json.Number("10.50"), "paid": true, "due_date": jan},
+		{"id": int64(2), "total": json.Number("20.00"), "paid": false, "due_date": mar},
+		{"id": int64(3), "total": json.Number("5.25"), "paid": false, "due_date": mar},
+		{"id": int64(4), "total": json.Number("4.25"), "paid": false, "due_date": nil},
+		{"id": int64(5), "total": nil, "paid": nil, "due_date": jan},
+	}
+	for _, o := range orders {
+		_, err := r.Insert(ctx, &OrderResource, o)
+		require.NoError(t, err)
+	}
+
+	aggregate := func(q repository.AggregateQuery) []map[string]any {
+		rows, err := aggregator.Aggregate(ctx, &OrderResource, q)
+		require.NoError(t, err)
+		return rows
+	}
+	decimal := func(v any) string {
+		require.IsType(t, json.Number(""), v)
+		n, ok := new(big.Rat).SetString(v.(json.Number).String())
+		require.True(t, ok, "%v", v)
+		return n.FloatString(2)
+	}
+	count := repository.Aggregate{Func: repository.AggCount, Field: repository.AllRows}
+	countTotal := repository.Aggregate{Func: repository.AggCount, Field: "total"}
+	sumTotal := repository.Aggregate{Func: repository.AggSum, Field: "total"}
+	avgTotal := repository.Aggregate{Func: repository.AggAvg, Field: "total"}
+	minDue := repository.Aggregate{Func: repository.AggMin, Field: "due_date"}
+	maxDue := repository.Aggregate{Func: repository.AggMax, Field: "due_date"}
+
+	// without group fields, all the rows are a single group, and nulls are not aggregated
+	rows := aggregate(repository.AggregateQuery{Aggregates: []repository.Aggregate{count, countTotal, sumTotal, avgTotal, minDue, maxDue}})
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(5), rows[0]["count"])
+	assert.Equal(t, int64(4), rows[0]["count_total"])
+	assert.Equal(t, "40.00", decimal(rows[0]["sum_total"]))
+	assert.Equal(t, "10.00", decimal(rows[0]["avg_total"]))
+	assert.Equal(t, "2023-01-10", rows[0]["min_due_date"])
+	assert.Equal(t, "2023-03-10", rows[0]["max_due_date"])
+
+	// groups are ordered by the group fields, with nulls first
+	rows = aggregate(repository.AggregateQuery{GroupBy: []string{"paid"}, Aggregates: []repository.Aggregate{count, sumTotal}})
+	require.Len(t, rows, 3)
+	assert.Equal(t, map[string]any{"paid": nil, "count": int64(1), "sum_total": nil}, rows[0])
+	assert.Equal(t, false, rows[1]["paid"])
+	assert.Equal(t, int64(3), rows[1]["count"])
+	assert.Equal(t, "29.50", decimal(rows[1]["sum_total"]))
+	assert.Equal(t, true, rows[2]["paid"])
+	assert.Equal(t, int64(1), rows[2]["count"])
+	assert.Equal(t, "10.50", decimal(rows[2]["sum_total"]))
+
+	rows = aggregate(repository.AggregateQuery{GroupBy: []string{"due_date", "paid"}, Aggregates: []repository.Aggregate{count}})
+	assert.Equal(t, []map[string]any{
+		{"due_date": nil, "paid": false, "count": int64(1)},
+		{"due_date": "2023-01-10", "paid": nil, "count": int64(1)},
+		{"due_date": "2023-01-10", "paid": true, "count": int64(1)},
+		{"due_date": "2023-03-10", "paid": false, "count": int64(2)},
+	}, rows)
+
+	// the rows are selected as in the search
+	rows = aggregate(repository.AggregateQuery{
+		Query:   repository.Query{Filters: []repository.Filter{{Field: "total", Op: resource.OpGt, Values: []any{json.Number("5")}}}},
+		GroupBy: []string{"paid"}, Aggregates: []repository.Aggregate{count},
+	})
+	assert.Equal(t, []map[string]any{{"paid": false, "count": int64(2)}, {"paid": true, "count": int64(1)}}, rows)
+	rows = aggregate(repository.AggregateQuery{
+		Query:      repository.Query{Where: map[string][]any{"id": {int64(6)}}},
+		Aggregates: []repository.Aggregate{count, sumTotal},
+	})
+	assert.Equal(t, []map[string]any{{"count": int64(0), "sum_total": nil}}, rows)
+	rows = aggregate(repository.AggregateQuery{
+		Query:   repository.Query{Where: map[string][]any{"id": {int64(6)}}},
+		GroupBy: []string{"paid"}, Aggregates: []repository.Aggregate{count},
+	})
+	assert.Equal(t, []map[string]any{}, rows)
+
+	for _, q := range []repository.AggregateQuery{
+		{},
+		{GroupBy: []string{"id"}, Aggregates: []repository.Aggregate{count}},
+		{GroupBy: []string{"paid", "paid"}, Aggregates: []repository.Aggregate{count}},
+		{Aggregates: []repository.Aggregate{{Func: repository.AggSum, Field: repository.AllRows}}},
+		{Aggregates: []repository.Aggregate{{Func: repository.AggSum, Field: "paid"}}},
+		{Aggregates: []repository.Aggregate{{Func: repository.AggMax, Field: "metadata"}}},
+		{Aggregates: []repository.Aggregate{{Func: "median", Field: "total"}}},
+		{Aggregates: []repository.Aggregate{count, count}},
+	} {
+		_, err := aggregator.Aggregate(ctx, &OrderResource, q)
+		assert.ErrorIs(t, err, repository.ErrInvalidQuery, "%v", q)
+	}
+}
+
+// pks returns the primary keys of the rows, in order
+func pks(rows []map[string]any, pk string) []string {
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i], _ = row[pk].(string)
+	}
+	return keys
+}
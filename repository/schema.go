@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// SchemaChecker is implemented by the repositories whose storage has a schema
+// that can drift from the resources, such as the tables of the sql repositories.
+type SchemaChecker interface {
+	// CheckSchema compares the fields, primary key and timestamp and soft delete
+	// fields of the resources with the schema of the storage
+	CheckSchema(ctx context.Context, resources ...*resource.Resource) (SchemaReport, error)
+}
+
+// DriftKind is the kind of a difference between a resource and the schema of the storage
+type DriftKind string
+
+const (
+	// DriftMissingTable is a resource without a table
+	DriftMissingTable DriftKind = "missing_table"
+	// DriftMissingColumn is a field, or a timestamp or soft delete field, without a column
+	DriftMissingColumn DriftKind = "missing_column"
+	// DriftPrimaryKey is a primary key that is not the primary key of the table
+	DriftPrimaryKey DriftKind = "primary_key"
+)
+
+// Drift is a difference between a resource and the schema of the storage
+type Drift struct {
+	Resource string    `json:"resource"`
+	Table    string    `json:"table"`
+	Kind     DriftKind `json:"kind"`
+	Column   string    `json:"column,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// SchemaReport is the result of a schema check, with the drifts of all resources
+type SchemaReport struct {
+	Drifts []Drift `json:"drifts"`
+}
+
+// OK returns true if no drift was found
+func (r SchemaReport) OK() bool {
+	return len(r.Drifts) == 0
+}
+
+// Mismatched returns true if the resource with the given name has drifted from the schema
+func (r SchemaReport) Mismatched(name string) bool {
+	for _, d := range r.Drifts {
+		if d.Resource == name {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the drifts, one per line
+func (r SchemaReport) String() string {
+	var sb strings.Builder
+	for _, d := range r.Drifts {
+		sb.WriteString(d.Resource)
+		sb.WriteString(": ")
+		sb.WriteString(d.Message)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
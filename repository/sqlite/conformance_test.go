@@ -0,0 +1,23 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.RunConformance(t, func(t *testing.T) repository.RepositoryInterface {
+		db, err := Open(":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		err = AutoMigrate(db, repositorytest.Resources()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewRepository(db)
+	})
+}
@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	sqldriver "database/sql/driver"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	"github.com/franciscoescher/gosimplerest/resource"
+	driver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Dialect is the SQLite syntax used by the sqlrepo query builder
+type Dialect struct{}
+
+// Compile-time check that Dialect implements the Dialect, ComparisonDialect and TextDialect interfaces
+var _ sqlrepo.Dialect = Dialect{}
+var _ sqlrepo.ComparisonDialect = Dialect{}
+var _ sqlrepo.TextDialect = Dialect{}
+
+// wordsFunction is the name of the function that splits a value into its words,
+// which SQLite can not do with its builtin functions
+const wordsFunction = "gosimplerest_words"
+
+func init() {
+	driver.MustRegisterDeterministicScalarFunction(wordsFunction, 1, func(ctx *driver.FunctionContext, args []sqldriver.Value) (sqldriver.Value, error) {
+		switch v := args[0].(type) {
+		case string:
+			return strings.Join(repository.TextTerms(v), " "), nil
+		case []byte:
+			return strings.Join(repository.TextTerms(string(v)), " "), nil
+		}
+		return args[0], nil
+	})
+}
+
+func (Dialect) Placeholder(n int) string {
+	return "?"
+}
+
+// Quote uses backticks, which SQLite also accepts, since an unknown identifier
+// in double quotes is silently taken as a string literal
+func (Dialect) Quote(identifier string) string {
+	return sqlrepo.QuoteIdentifier(identifier, "`")
+}
+
+func (Dialect) CurrentTimestamp() string {
+	return "CURRENT_TIMESTAMP"
+}
+
+// Limit uses -1 as limit when only the offset is given,
+// since SQLite does not accept an offset without a limit
+func (Dialect) Limit(limit, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	l := "-1"
+	if limit > 0 {
+		l = strconv.Itoa(limit)
+	}
+	if offset <= 0 {
+		return "LIMIT " + l
+	}
+	return "LIMIT " + l + " OFFSET " + strconv.Itoa(offset)
+}
+
+func (Dialect) Returning(pk string) string {
+	return ""
+}
+
+// TranslateError converts the extended result codes of SQLite to repository errors
+func (Dialect) TranslateError(err error) error {
+	var sqliteErr *driver.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		return repository.NewError(repository.ErrConflict, err)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY, sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return repository.NewError(repository.ErrConstraint, err)
+	case sqlite3.SQLITE_ERROR:
+		// unknown tables and columns have no specific code
+		msg := sqliteErr.Error()
+		if strings.Contains(msg, "no such column") || strings.Contains(msg, "has no column named") || strings.Contains(msg, "no such table") || strings.Contains(msg, "syntax error") {
+			return repository.NewError(repository.ErrInvalidQuery, err)
+		}
+	}
+	return err
+}
+
+// Compared converts decimals, stored as text, to numbers, so they are not compared as strings
+func (Dialect) Compared(expr string, t resource.Type) string {
+	if t == resource.TypeDecimal {
+		return "CAST(" + expr + " AS NUMERIC)"
+	}
+	return expr
+}
+
+// Words uses the function registered by the package, which splits the value into
+// the words of repository.TextTerms
+func (Dialect) Words(column string) string {
+	return wordsFunction + "(" + column + ")"
+}
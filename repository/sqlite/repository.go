@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+
+	// pure go sqlite driver, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// Repository is the implementation of the RepositoryInterface for SQLite database.
+type Repository struct {
+	sqlrepo.Repository
+}
+
+// NewRepository returns a new SQLite Repository
+func NewRepository(db *sql.DB) Repository {
+	return Repository{Repository: sqlrepo.NewRepository(db, Dialect{})}
+}
+
+// Open opens a SQLite database with the pure go driver.
+// The dsn is the path to the database file, or ":memory:" for an in memory database.
+// SQLite serializes writes, so the pool is limited to one connection, which also
+// keeps in memory databases from being created once per connection.
+func Open(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Compile-time check that Repository implements the Repository, Transactional, ReadTransactional and SchemaChecker interfaces
+var _ repository.RepositoryInterface = (*Repository)(nil)
+var _ repository.Transactional = (*Repository)(nil)
+var _ repository.ReadTransactional = (*Repository)(nil)
+var _ repository.SchemaChecker = (*Repository)(nil)
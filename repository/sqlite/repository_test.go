@@ -0,0 +1,220 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	null "gopkg.in/guregu/null.v3"
+)
+
+var testResource = resource.Resource{
+	Name:       "users_test",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":         {},
+		"first_name": {},
+		"created_at": {},
+		"deleted_at": {},
+	},
+	AutoIncrementalPK: true,
+	SoftDeleteField:   null.NewString("deleted_at", true),
+	CreatedAtField:    null.NewString("created_at", true),
+}
+
+func newTestRepository(t *testing.T) Repository {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(`CREATE TABLE users_test (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		first_name TEXT,
+		created_at DATETIME,
+		deleted_at DATETIME
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRepository(db)
+}
+
+func TestInsertAndFind(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	id, err := r.Insert(ctx, &testResource, map[string]any{"first_name": "Fulano", "created_at": created})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	id, err = r.Insert(ctx, &testResource, map[string]any{"first_name": "Ciclano", "created_at": created})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), id)
+
+	row, err := r.Find(ctx, &testResource, int64(1), repository.FindOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Fulano", row["first_name"])
+	assert.True(t, created.Equal(row["created_at"].(time.Time)))
+	assert.Nil(t, row["deleted_at"])
+
+	row, err = r.Find(ctx, &testResource, int64(3), repository.FindOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, row, 0)
+}
+
+func TestSearch(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Fulano", "Ciclano", "Beltrano"} {
+		_, err := r.Insert(ctx, &testResource, map[string]any{"first_name": name})
+		assert.NoError(t, err)
+	}
+
+	rows, err := r.Search(ctx, &testResource, repository.Query{Where: map[string][]any{"first_name": {"Beltrano", "Fulano"}}})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "Fulano", rows[0]["first_name"])
+	assert.Equal(t, "Beltrano", rows[1]["first_name"])
+
+	rows, err = r.Search(ctx, &testResource, repository.Query{Where: map[string][]any{"first_name": {"Nobody"}}})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 0)
+}
+
+func TestUpdate(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	id, err := r.Insert(ctx, &testResource, map[string]any{"first_name": "Fulano"})
+	assert.NoError(t, err)
+
+	ok, err := r.Update(ctx, &testResource, map[string]any{"id": id, "first_name": "Ciclano"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	row, err := r.Find(ctx, &testResource, id, repository.FindOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ciclano", row["first_name"])
+
+	ok, err = r.Update(ctx, &testResource, map[string]any{"id": id + 1, "first_name": "Ciclano"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestDelete(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	id, err := r.Insert(ctx, &testResource, map[string]any{"first_name": "Fulano"})
+	assert.NoError(t, err)
+
+	// soft delete stamps the deleted at field
+	err = r.Delete(ctx, &testResource, id)
+	assert.NoError(t, err)
+	row, err := r.Find(ctx, &testResource, id, repository.FindOptions{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.IsType(t, time.Time{}, row["deleted_at"])
+
+	// hard delete removes the row
+	hard := testResource
+	hard.SoftDeleteField = null.String{}
+	err = r.Delete(ctx, &hard, id)
+	assert.NoError(t, err)
+	row, err = r.Find(ctx, &hard, id, repository.FindOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, row, 0)
+
+	err = r.Delete(ctx, &hard, id)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestCancelledContext(t *testing.T) {
+	r := newTestRepository(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := r.Insert(ctx, &testResource, map[string]any{"first_name": "Fulano"})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = r.Search(ctx, &testResource, repository.Query{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWithTx(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	// committed when fn returns nil
+	var id int64
+	err := r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		var err error
+		id, err = tx.Insert(ctx, &testResource, map[string]any{"first_name": "Fulano"})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Update(ctx, &testResource, map[string]any{"id": id, "first_name": "Ciclano"})
+		return err
+	})
+	assert.NoError(t, err)
+	row, err := r.Find(ctx, &testResource, id, repository.FindOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ciclano", row["first_name"])
+
+	// rolled back when fn returns an error
+	errRollback := errors.New("rollback")
+	err = r.WithTx(ctx, func(tx repository.RepositoryInterface) error {
+		_, err := tx.Insert(ctx, &testResource, map[string]any{"first_name": "Beltrano"})
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	rows, err := r.Search(ctx, &testResource, repository.Query{Where: map[string][]any{"first_name": {"Beltrano"}}})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 0)
+}
+
+func TestWithReadTx(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	id, err := r.Insert(ctx, &testResource, map[string]any{"first_name": "Fulano"})
+	assert.NoError(t, err)
+
+	// reads see the rows, and writes fail, also in the transactions started from it
+	err = r.WithReadTx(ctx, func(tx repository.RepositoryInterface) error {
+		row, err := tx.Find(ctx, &testResource, id, repository.FindOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "Fulano", row["first_name"])
+		_, err = tx.Insert(ctx, &testResource, map[string]any{"first_name": "Ciclano"})
+		assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+		return repository.WithTx(ctx, tx, func(tx repository.RepositoryInterface) error {
+			_, err := tx.Update(ctx, &testResource, map[string]any{"id": id, "first_name": "Beltrano"})
+			assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+			return tx.Delete(ctx, &testResource, id)
+		})
+	})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+	rows, err := r.Search(ctx, &testResource, repository.Query{})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Fulano", rows[0]["first_name"])
+}
+
+func TestTranslateError(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	_, err := r.Insert(ctx, &testResource, map[string]any{"first_name": "Fulano", "unknown": "value"})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+
+	_, err = r.Search(ctx, &testResource, repository.Query{Where: map[string][]any{"unknown": {"value"}}})
+	assert.ErrorIs(t, err, repository.ErrInvalidQuery)
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/repository/sqlrepo"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Compile-time check that Dialect implements the SchemaDialect interface
+var _ sqlrepo.SchemaDialect = Dialect{}
+
+// ColumnType uses the type names that give each column the affinity of its type.
+// Decimals are stored as text, since the numeric affinity rounds them to floats.
+func (Dialect) ColumnType(t resource.Type, key bool) string {
+	switch t {
+	case resource.TypeInt:
+		return "INTEGER"
+	case resource.TypeFloat:
+		return "REAL"
+	case resource.TypeBool:
+		return "BOOLEAN"
+	case resource.TypeTime:
+		return "DATETIME"
+	case resource.TypeDate:
+		return "DATE"
+	}
+	return "TEXT"
+}
+
+// AutoIncrementColumn is an INTEGER primary key, which SQLite uses as the rowid
+func (Dialect) AutoIncrementColumn() string {
+	return "INTEGER NOT NULL"
+}
+
+func (Dialect) Columns(ctx context.Context, db *sql.DB, table string) ([]sqlrepo.Column, error) {
+	return sqlrepo.QueryColumns(ctx, db, `SELECT name, pk > 0 FROM pragma_table_info(?) ORDER BY cid`, table)
+}
+
+func (Dialect) Indexes(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	return sqlrepo.QueryStrings(ctx, db, `SELECT name FROM pragma_index_list(?)`, table)
+}
+
+// NewMigrator returns a new Migrator for a SQLite database
+func NewMigrator(db *sql.DB) *sqlrepo.Migrator {
+	return sqlrepo.NewMigrator(db, Dialect{})
+}
+
+// AutoMigrate creates the missing tables of the resources and adds their missing columns and indexes
+func AutoMigrate(db *sql.DB, resources ...*resource.Resource) error {
+	return NewMigrator(db).AutoMigrate(context.Background(), resources...)
+}
+
+// CheckSchema compares the resources with their tables, returning the drifts found
+func CheckSchema(db *sql.DB, resources ...*resource.Resource) (repository.SchemaReport, error) {
+	return NewMigrator(db).CheckSchema(context.Background(), resources...)
+}
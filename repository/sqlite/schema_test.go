@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoMigrate(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	users := resource.Resource{
+		Name:              "users",
+		PrimaryKey:        "id",
+		AutoIncrementalPK: true,
+		Fields: map[string]resource.Field{
+			"id":         {Type: resource.TypeInt},
+			"first_name": {Type: resource.TypeString},
+		},
+	}
+	require.NoError(t, AutoMigrate(db, &users))
+
+	r := NewRepository(db)
+	id, err := r.Insert(ctx, &users, map[string]any{"first_name": "Fulano"})
+	require.NoError(t, err)
+
+	// new fields are added to the existing table, keeping its rows
+	users.Fields["email"] = resource.Field{Type: resource.TypeString, Index: true}
+	m := NewMigrator(db)
+	statements, err := m.Plan(ctx, &users)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE `users` ADD COLUMN `email` TEXT",
+		"CREATE INDEX `idx_users_email` ON `users` (`email`)",
+	}, statements)
+
+	// dry run only writes the statements
+	out := new(bytes.Buffer)
+	m.DryRun = true
+	m.Output = out
+	require.NoError(t, m.AutoMigrate(ctx, &users))
+	assert.Equal(t, "ALTER TABLE `users` ADD COLUMN `email` TEXT;\nCREATE INDEX `idx_users_email` ON `users` (`email`);\n", out.String())
+	_, err = r.Find(ctx, &users, id, repository.FindOptions{})
+	assert.Error(t, err)
+
+	require.NoError(t, AutoMigrate(db, &users))
+	row, err := r.Find(ctx, &users, id, repository.FindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": id, "first_name": "Fulano", "email": nil}, row)
+
+	statements, err = m.Plan(ctx, &users)
+	require.NoError(t, err)
+	assert.Empty(t, statements)
+}
+
+func TestCheckSchema(t *testing.T) {
+	db, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	users := resource.Resource{
+		Name:       "users",
+		PrimaryKey: "uuid",
+		Fields: map[string]resource.Field{
+			"uuid":       {Type: resource.TypeUUID},
+			"first_name": {Type: resource.TypeString},
+		},
+	}
+	notes := resource.Resource{
+		Name:       "notes",
+		PrimaryKey: "id",
+		Fields:     map[string]resource.Field{"id": {}},
+	}
+	require.NoError(t, AutoMigrate(db, &users))
+
+	report, err := CheckSchema(db, &users)
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+
+	// a column renamed in the database
+	_, err = db.Exec("ALTER TABLE users RENAME COLUMN first_name TO name")
+	require.NoError(t, err)
+	report, err = NewRepository(db).CheckSchema(context.Background(), &users, &notes)
+	require.NoError(t, err)
+	assert.Equal(t, "users: field first_name is not a column of table users\nnotes: table notes does not exist\n", report.String())
+	assert.True(t, report.Mismatched("users"))
+	assert.True(t, report.Mismatched("notes"))
+}
@@ -0,0 +1,67 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Compile-time check that Repository implements the Aggregator interface
+var _ repository.Aggregator = (*Repository)(nil)
+
+func (r Repository) Aggregate(ctx context.Context, b *resource.Resource, q repository.AggregateQuery) ([]map[string]any, error) {
+	err := repository.ValidateAggregate(b, q)
+	if err != nil {
+		return nil, err
+	}
+	index, err := r.fullTextIndexed(ctx, b, q.Query)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, args := buildAggregate(r.dialect, b, q, index)
+	response, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, r.fullTextFailed(b, index, err)
+	}
+	defer response.Close()
+	return parseAggregates(b, q, response)
+}
+
+// parseAggregates reads the groups selected by buildAggregate, converting the values
+// of the group fields to their types, and the aggregates to the types of Aggregate.Type
+func parseAggregates(b *resource.Resource, q repository.AggregateQuery, rows *sql.Rows) ([]map[string]any, error) {
+	names := make([]string, 0, len(q.GroupBy)+len(q.Aggregates))
+	types := make([]resource.Type, 0, cap(names))
+	for _, field := range q.GroupBy {
+		names = append(names, field)
+		types = append(types, b.Fields[field].Type)
+	}
+	for _, a := range q.Aggregates {
+		names = append(names, a.Name())
+		types = append(types, a.Type(b))
+	}
+
+	results := make([]map[string]any, 0)
+	for rows.Next() {
+		values := make([]any, len(names))
+		scanArgs := make([]any, len(names))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+		err := rows.Scan(scanArgs...)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]any, len(names))
+		for i, name := range names {
+			result[name], err = types[i].Scan(values[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
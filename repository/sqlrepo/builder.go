@@ -0,0 +1,448 @@
+package sqlrepo
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// builder writes a sql statement, keeping track of the arguments
+// bound to it so placeholders are numbered by the dialect
+type builder struct {
+	d    Dialect
+	sb   strings.Builder
+	args []any
+	// fullTextIndex makes the full-text search match the index of the dialect, instead of like patterns
+	fullTextIndex bool
+}
+
+func newBuilder(d Dialect) *builder {
+	return &builder{d: d, args: make([]any, 0)}
+}
+
+// write appends the strings to the statement
+func (q *builder) write(strs ...string) *builder {
+	for _, s := range strs {
+		q.sb.WriteString(s)
+	}
+	return q
+}
+
+// bind adds an argument to the statement, returning its placeholder
+func (q *builder) bind(v any) string {
+	q.args = append(q.args, v)
+	return q.d.Placeholder(len(q.args))
+}
+
+// ident quotes an identifier
+func (q *builder) ident(name string) string {
+	return q.d.Quote(name)
+}
+
+// idents quotes a list of identifiers, joining them with commas
+func (q *builder) idents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = q.d.Quote(name)
+	}
+	return strings.Join(quoted, ",")
+}
+
+// build returns the statement and its arguments
+func (q *builder) build() (string, []any) {
+	return q.sb.String(), q.args
+}
+
+// sortedKeys returns the keys of a map in alphabetical order, so statements are stable
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// notDeleted writes the condition that excludes the soft deleted rows, if the resource uses soft deletes
+func (q *builder) notDeleted(b *resource.Resource) {
+	if b.SoftDeleteField.Valid {
+		q.write(` AND `, q.ident(b.SoftDeleteField.String), ` IS NULL`)
+	}
+}
+
+// buildFind returns the statement that selects the columns of a row by its primary key
+func buildFind(d Dialect, b *resource.Resource, columns []string, id any, opts repository.FindOptions) (string, []any) {
+	q := newBuilder(d)
+	q.write(`SELECT `, q.idents(columns), ` FROM `, q.ident(b.Table()))
+	q.write(` WHERE `, q.ident(b.PrimaryKey), ` = `, q.bind(id))
+	if !opts.IncludeDeleted {
+		q.notDeleted(b)
+	}
+	q.write(` `, d.Limit(1, 0))
+	return q.build()
+}
+
+// buildSearch returns the statement that selects the columns of the rows matching the query.
+// Values of the same field are ORed and different fields are ANDed.
+// With a cursor before a row, the rows are selected in the reverse order,
+// and must be reversed by the caller. fullTextIndex is true if the full-text
+// fields of the resource have an index, which the dialect must match.
+func buildSearch(d Dialect, b *resource.Resource, columns []string, query repository.Query, fullTextIndex bool) (string, []any, error) {
+	q := newBuilder(d)
+	q.fullTextIndex = fullTextIndex
+	q.write(`SELECT `, q.idents(columns), ` FROM `, q.ident(b.Table()))
+	conds := q.conditions(b, query)
+	order := query.Order(b.PrimaryKey)
+	backwards := query.Cursor != nil && query.Cursor.Before
+	if backwards {
+		for i := range order {
+			order[i].Desc = !order[i].Desc
+		}
+	}
+	if query.Cursor != nil {
+		position, err := query.Position(b.PrimaryKey)
+		if err != nil {
+			return "", nil, err
+		}
+		conds = append(conds, q.after(b, order, position))
+	}
+	q.where(conds)
+	q.orderBy(b, order)
+	if limit := d.Limit(query.Limit, query.Offset); limit != "" {
+		q.write(` `, limit)
+	}
+	sqlStr, args := q.build()
+	return sqlStr, args, nil
+}
+
+// orderBy writes the order by clause. Null values are ordered first, as in
+// MySQL and SQLite, so all databases return the rows in the same order.
+func (q *builder) orderBy(b *resource.Resource, order []repository.Sort) {
+	terms := make([]string, 0, len(order)*2)
+	for _, s := range order {
+		nulls, dir := ` DESC`, ``
+		if s.Desc {
+			nulls, dir = ``, ` DESC`
+		}
+		if s.Field != b.PrimaryKey {
+			terms = append(terms, q.ident(s.Field)+` IS NULL`+nulls)
+		}
+		terms = append(terms, q.column(b, s.Field)+dir)
+	}
+	q.write(` ORDER BY `, strings.Join(terms, `,`))
+}
+
+// after returns the condition of the rows after the position in the order,
+// comparing the fields one by one, as a tuple
+func (q *builder) after(b *resource.Resource, order []repository.Sort, position []any) string {
+	terms := make([]string, len(order))
+	for i := range order {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, q.equal(b, order[j].Field, position[j]))
+		}
+		parts = append(parts, q.beyond(b, order[i], position[i]))
+		terms[i] = strings.Join(parts, ` AND `)
+		if len(parts) > 1 {
+			terms[i] = `(` + terms[i] + `)`
+		}
+	}
+	if len(terms) > 1 {
+		return `(` + strings.Join(terms, ` OR `) + `)`
+	}
+	return terms[0]
+}
+
+// equal returns the condition of the values of a field equal to v
+func (q *builder) equal(b *resource.Resource, field string, v any) string {
+	if v == nil {
+		return q.ident(field) + ` IS NULL`
+	}
+	return q.column(b, field) + ` = ` + q.value(b, field, v)
+}
+
+// beyond returns the condition of the values of a field after v in the order,
+// where null values come first. The primary key is never null.
+func (q *builder) beyond(b *resource.Resource, s repository.Sort, v any) string {
+	switch {
+	case v == nil && s.Desc:
+		return `1 = 0`
+	case v == nil:
+		return q.ident(s.Field) + ` IS NOT NULL`
+	case s.Desc && s.Field != b.PrimaryKey:
+		return `(` + q.column(b, s.Field) + ` < ` + q.value(b, s.Field, v) + ` OR ` + q.ident(s.Field) + ` IS NULL)`
+	}
+	if s.Desc {
+		return q.column(b, s.Field) + ` < ` + q.value(b, s.Field, v)
+	}
+	return q.column(b, s.Field) + ` > ` + q.value(b, s.Field, v)
+}
+
+// buildCount returns the statement that counts the rows matching the query,
+// ignoring its limit, offset and cursor
+func buildCount(d Dialect, b *resource.Resource, query repository.Query, fullTextIndex bool) (string, []any) {
+	q := newBuilder(d)
+	q.fullTextIndex = fullTextIndex
+	q.write(`SELECT COUNT(*) FROM `, q.ident(b.Table()))
+	q.where(q.conditions(b, query))
+	return q.build()
+}
+
+// buildAggregate returns the statement that groups the rows matching the query, selecting
+// the group fields and then the aggregates, named as in the rows of repository.Aggregator.
+// Groups are ordered as the sorts, with null values first.
+func buildAggregate(d Dialect, b *resource.Resource, query repository.AggregateQuery, fullTextIndex bool) (string, []any) {
+	q := newBuilder(d)
+	q.fullTextIndex = fullTextIndex
+	selects := make([]string, 0, len(query.GroupBy)+len(query.Aggregates))
+	for _, field := range query.GroupBy {
+		selects = append(selects, q.ident(field))
+	}
+	for _, a := range query.Aggregates {
+		arg := `*`
+		if a.Field != repository.AllRows {
+			arg = q.ident(a.Field)
+			if a.Func != repository.AggCount {
+				arg = q.column(b, a.Field)
+			}
+		}
+		selects = append(selects, strings.ToUpper(string(a.Func))+`(`+arg+`) AS `+q.ident(a.Name()))
+	}
+	q.write(`SELECT `, strings.Join(selects, `,`), ` FROM `, q.ident(b.Table()))
+	q.where(q.conditions(b, query.Query))
+	if len(query.GroupBy) > 0 {
+		q.write(` GROUP BY `, q.idents(query.GroupBy))
+		order := make([]repository.Sort, len(query.GroupBy))
+		for i, field := range query.GroupBy {
+			order[i] = repository.Sort{Field: field}
+		}
+		q.orderBy(b, order)
+	}
+	return q.build()
+}
+
+// conditions returns the conditions of the fields of the query,
+// and the one that excludes the soft deleted rows
+func (q *builder) conditions(b *resource.Resource, query repository.Query) []string {
+	conds := make([]string, 0, len(query.Where)+2)
+	for _, field := range sortedKeys(query.Where) {
+		values := query.Where[field]
+		if len(values) == 1 {
+			conds = append(conds, q.column(b, field)+` = `+q.value(b, field, values[0]))
+			continue
+		}
+		conds = append(conds, q.column(b, field)+` IN (`+q.values(b, field, values)+`)`)
+	}
+	for _, f := range query.Filters {
+		conds = append(conds, q.filter(b, f))
+	}
+	if query.Expr != nil {
+		conds = append(conds, q.expr(b, query.Expr))
+	}
+	if terms := repository.TextTerms(query.Text); len(terms) > 0 {
+		conds = append(conds, q.text(b, terms))
+	}
+	if b.SoftDeleteField.Valid && !query.IncludeDeleted {
+		conds = append(conds, q.ident(b.SoftDeleteField.String)+` IS NULL`)
+	}
+	return conds
+}
+
+// comparisons are the sql operators of the filters that compare a single value
+var comparisons = map[resource.Operator]string{
+	resource.OpEq:  ` = `,
+	resource.OpNe:  ` <> `,
+	resource.OpGt:  ` > `,
+	resource.OpGte: ` >= `,
+	resource.OpLt:  ` < `,
+	resource.OpLte: ` <= `,
+}
+
+// filter returns the condition of a filter, validated by repository.ValidateFilters.
+// Patterns are compared in lower case, since databases differ on the case sensitivity of LIKE.
+func (q *builder) filter(b *resource.Resource, f repository.Filter) string {
+	switch f.Op {
+	case resource.OpLike:
+		return `LOWER(` + q.ident(f.Field) + `) LIKE LOWER(` + q.bind(f.Values[0]) + `)`
+	case resource.OpIn:
+		return q.column(b, f.Field) + ` IN (` + q.values(b, f.Field, f.Values) + `)`
+	case resource.OpNin:
+		return q.column(b, f.Field) + ` NOT IN (` + q.values(b, f.Field, f.Values) + `)`
+	case resource.OpNull:
+		if f.Values[0].(bool) {
+			return q.ident(f.Field) + ` IS NULL`
+		}
+		return q.ident(f.Field) + ` IS NOT NULL`
+	}
+	return q.column(b, f.Field) + comparisons[f.Op] + q.value(b, f.Field, f.Values[0])
+}
+
+// expr returns the condition of an expression, validated by repository.ValidateExpr.
+// Ands and ors are enclosed in parentheses, so the precedence of the tree is kept.
+func (q *builder) expr(b *resource.Resource, e repository.Expr) string {
+	switch e := e.(type) {
+	case repository.And:
+		return q.exprs(b, e, ` AND `)
+	case repository.Or:
+		return q.exprs(b, e, ` OR `)
+	case repository.Not:
+		return `NOT (` + q.expr(b, e.Expr) + `)`
+	case repository.Related:
+		return q.related(e)
+	case repository.Filter:
+		return q.filter(b, e)
+	}
+	return ``
+}
+
+// related returns the condition of a related expression, a subquery of the keys of the other
+// resource. Null keys are left out, so a Not of it matches as in the in-memory repositories.
+func (q *builder) related(e repository.Related) string {
+	conds := append(q.conditions(e.Resource, repository.Query{Where: e.Where}), q.ident(e.Key)+` IS NOT NULL`)
+	return q.ident(e.Field) + ` IN (SELECT ` + q.ident(e.Key) + ` FROM ` + q.ident(e.Resource.Table()) + ` WHERE ` + strings.Join(conds, ` AND `) + `)`
+}
+
+// exprs joins the conditions of the expressions with the operator
+func (q *builder) exprs(b *resource.Resource, exprs []repository.Expr, op string) string {
+	conds := make([]string, len(exprs))
+	for i, e := range exprs {
+		conds[i] = q.expr(b, e)
+	}
+	return `(` + strings.Join(conds, op) + `)`
+}
+
+// text returns the condition of a full-text search, validated by repository.ValidateText.
+// Without an index, each term is matched at the start of the column or after a space,
+// in lower case as the like filters, and split into words by the dialect if it implements TextDialect.
+func (q *builder) text(b *resource.Resource, terms []string) string {
+	fields := b.FullTextFields()
+	if f, ok := q.d.(FullTextDialect); ok && q.fullTextIndex {
+		return f.Match(q.idents(fields), q.bind(f.MatchTerms(terms)))
+	}
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		if t, ok := q.d.(TextDialect); ok {
+			columns[i] = t.Words(q.ident(field))
+		} else {
+			columns[i] = `LOWER(` + q.ident(field) + `)`
+		}
+	}
+	conds := make([]string, len(terms))
+	for i, term := range terms {
+		likes := make([]string, 0, len(fields)*2)
+		for _, column := range columns {
+			likes = append(likes, column+` LIKE `+q.bind(term+`%`), column+` LIKE `+q.bind(`% `+term+`%`))
+		}
+		conds[i] = `(` + strings.Join(likes, ` OR `) + `)`
+	}
+	return strings.Join(conds, ` AND `)
+}
+
+// column returns the expression of a column that is compared with values or sorted,
+// converted by the dialect if it implements ComparisonDialect
+func (q *builder) column(b *resource.Resource, field string) string {
+	return q.compared(b, field, q.ident(field))
+}
+
+// value binds a value compared with the column of a field, as column
+func (q *builder) value(b *resource.Resource, field string, v any) string {
+	return q.compared(b, field, q.bind(v))
+}
+
+// values binds a list of values compared with the column of a field, joining them with commas
+func (q *builder) values(b *resource.Resource, field string, values []any) string {
+	in := make([]string, len(values))
+	for i, v := range values {
+		in[i] = q.value(b, field, v)
+	}
+	return strings.Join(in, ",")
+}
+
+// compared converts the expression with the dialect, if it implements ComparisonDialect
+func (q *builder) compared(b *resource.Resource, field string, expr string) string {
+	if c, ok := q.d.(ComparisonDialect); ok {
+		return c.Compared(expr, b.Fields[field].Type)
+	}
+	return expr
+}
+
+// where writes the where clause, if there are conditions
+func (q *builder) where(conds []string) {
+	if len(conds) > 0 {
+		q.write(` WHERE `, strings.Join(conds, ` AND `))
+	}
+}
+
+// buildInsert returns the statement that inserts a row.
+// The primary key is skipped if it is auto incremental.
+func buildInsert(d Dialect, b *resource.Resource, data map[string]any) (string, []any) {
+	fields := make([]string, 0, len(data))
+	for _, key := range sortedKeys(data) {
+		if key == b.PrimaryKey && b.AutoIncrementalPK {
+			continue
+		}
+		fields = append(fields, key)
+	}
+
+	q := newBuilder(d)
+	values := make([]string, len(fields))
+	for i, key := range fields {
+		values[i] = q.bind(data[key])
+	}
+	q.write(`INSERT INTO `, q.ident(b.Table()), ` (`, q.idents(fields), `) VALUES (`, strings.Join(values, ","), `)`)
+	if b.AutoIncrementalPK {
+		if ret := d.Returning(b.PrimaryKey); ret != "" {
+			q.write(` `, ret)
+		}
+	}
+	return q.build()
+}
+
+// buildUpdate returns the statement that updates the row identified by the primary key in data,
+// if it is not soft deleted
+func buildUpdate(d Dialect, b *resource.Resource, data map[string]any) (string, []any) {
+	q := newBuilder(d)
+	fields := sortedKeys(data)
+	set := make([]string, len(fields))
+	for i, key := range fields {
+		set[i] = q.ident(key) + ` = ` + q.bind(data[key])
+	}
+	q.write(`UPDATE `, q.ident(b.Table()), ` SET `, strings.Join(set, ","))
+	q.write(` WHERE `, q.ident(b.PrimaryKey), ` = `, q.bind(data[b.PrimaryKey]))
+	q.notDeleted(b)
+	return q.build()
+}
+
+// buildDelete returns the statement that deletes a row by its primary key.
+// If the resource uses soft deletes, the row is updated with the current timestamp instead.
+func buildDelete(d Dialect, b *resource.Resource, id any) (string, []any) {
+	q := newBuilder(d)
+	if b.SoftDeleteField.Valid {
+		q.write(`UPDATE `, q.ident(b.Table()), ` SET `, q.ident(b.SoftDeleteField.String), ` = `, d.CurrentTimestamp())
+	} else {
+		q.write(`DELETE FROM `, q.ident(b.Table()))
+	}
+	q.write(` WHERE `, q.ident(b.PrimaryKey), ` = `, q.bind(id))
+	q.notDeleted(b)
+	return q.build()
+}
+
+// buildRestore returns the statement that clears the soft delete field of a soft deleted row
+func buildRestore(d Dialect, b *resource.Resource, id any) (string, []any) {
+	q := newBuilder(d)
+	q.write(`UPDATE `, q.ident(b.Table()), ` SET `, q.ident(b.SoftDeleteField.String), ` = NULL`)
+	q.write(` WHERE `, q.ident(b.PrimaryKey), ` = `, q.bind(id))
+	q.write(` AND `, q.ident(b.SoftDeleteField.String), ` IS NOT NULL`)
+	return q.build()
+}
+
+// buildPurge returns the statement that deletes a soft deleted row
+func buildPurge(d Dialect, b *resource.Resource, id any) (string, []any) {
+	q := newBuilder(d)
+	q.write(`DELETE FROM `, q.ident(b.Table()))
+	q.write(` WHERE `, q.ident(b.PrimaryKey), ` = `, q.bind(id))
+	q.write(` AND `, q.ident(b.SoftDeleteField.String), ` IS NOT NULL`)
+	return q.build()
+}
@@ -0,0 +1,349 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// questionDialect binds parameters with ? and reads ids with LastInsertId
+type questionDialect struct{}
+
+func (questionDialect) Placeholder(n int) string       { return "?" }
+func (questionDialect) Quote(identifier string) string { return QuoteIdentifier(identifier, "`") }
+func (questionDialect) CurrentTimestamp() string       { return "NOW()" }
+func (questionDialect) Returning(pk string) string     { return "" }
+func (questionDialect) Limit(limit, offset int) string { return limitClause(limit, offset) }
+func (questionDialect) TranslateError(err error) error { return err }
+
+// dollarDialect binds numbered parameters and reads ids with RETURNING
+type dollarDialect struct{}
+
+func (dollarDialect) Placeholder(n int) string       { return "$" + strconv.Itoa(n) }
+func (dollarDialect) Quote(identifier string) string { return QuoteIdentifier(identifier, `"`) }
+func (dollarDialect) CurrentTimestamp() string       { return "now()" }
+func (dollarDialect) Returning(pk string) string     { return `RETURNING "` + pk + `"` }
+func (dollarDialect) Limit(limit, offset int) string { return limitClause(limit, offset) }
+func (dollarDialect) TranslateError(err error) error { return err }
+
+// limitClause writes the limit and offset given, for the test dialects
+func limitClause(limit, offset int) string {
+	clauses := make([]string, 0, 2)
+	if limit > 0 {
+		clauses = append(clauses, "LIMIT "+strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		clauses = append(clauses, "OFFSET "+strconv.Itoa(offset))
+	}
+	return strings.Join(clauses, " ")
+}
+
+var testResource = resource.Resource{
+	Name:       "users",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":         {},
+		"first_name": {},
+		"deleted_at": {},
+	},
+	AutoIncrementalPK: true,
+	SoftDeleteField:   null.NewString("deleted_at", true),
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`users`", QuoteIdentifier("users", "`"))
+	assert.Equal(t, "`app`.`users`", QuoteIdentifier("app.users", "`"))
+	assert.Equal(t, `"we""ird"`, QuoteIdentifier(`we"ird`, `"`))
+}
+
+func TestBuildFind(t *testing.T) {
+	sql, args := buildFind(questionDialect{}, &testResource, testResource.GetFieldNames(), 1, repository.FindOptions{})
+	assert.Equal(t, "SELECT `deleted_at`,`first_name`,`id` FROM `users` WHERE `id` = ? AND `deleted_at` IS NULL LIMIT 1", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args = buildFind(dollarDialect{}, &testResource, testResource.GetFieldNames(), 1, repository.FindOptions{IncludeDeleted: true})
+	assert.Equal(t, `SELECT "deleted_at","first_name","id" FROM "users" WHERE "id" = $1 LIMIT 1`, sql)
+	assert.Equal(t, []any{1}, args)
+}
+
+func TestBuildFindColumns(t *testing.T) {
+	sql, _ := buildFind(questionDialect{}, &testResource, []string{"first_name", "id"}, 1, repository.FindOptions{IncludeDeleted: true})
+	assert.Equal(t, "SELECT `first_name`,`id` FROM `users` WHERE `id` = ? LIMIT 1", sql)
+}
+
+func TestBuildSearch(t *testing.T) {
+	query := repository.Query{Where: map[string][]any{
+		"id":         {"1", "2"},
+		"first_name": {"Fulano"},
+	}}
+	sql, args, err := buildSearch(questionDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `deleted_at`,`first_name`,`id` FROM `users` WHERE `first_name` = ? AND `id` IN (?,?) AND `deleted_at` IS NULL ORDER BY `id`", sql)
+	assert.Equal(t, []any{"Fulano", "1", "2"}, args)
+
+	query.IncludeDeleted = true
+	sql, args, err = buildSearch(dollarDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "deleted_at","first_name","id" FROM "users" WHERE "first_name" = $1 AND "id" IN ($2,$3) ORDER BY "id"`, sql)
+	assert.Equal(t, []any{"Fulano", "1", "2"}, args)
+
+	sql, args, err = buildSearch(dollarDialect{}, &testResource, testResource.GetFieldNames(), repository.Query{IncludeDeleted: true}, false)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "deleted_at","first_name","id" FROM "users" ORDER BY "id"`, sql)
+	assert.Len(t, args, 0)
+}
+
+func TestBuildSearchPagination(t *testing.T) {
+	query := repository.Query{
+		Where:  map[string][]any{"first_name": {"Fulano"}},
+		Limit:  10,
+		Offset: 20,
+		Cursor: &repository.Cursor{Key: 5},
+	}
+	sql, args, err := buildSearch(questionDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `deleted_at`,`first_name`,`id` FROM `users` WHERE `first_name` = ? AND `deleted_at` IS NULL AND `id` > ? ORDER BY `id` LIMIT 10 OFFSET 20", sql)
+	assert.Equal(t, []any{"Fulano", 5}, args)
+
+	// rows before the cursor are selected backwards
+	query.Cursor.Before = true
+	query.Offset = 0
+	sql, args, err = buildSearch(dollarDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "deleted_at","first_name","id" FROM "users" WHERE "first_name" = $1 AND "deleted_at" IS NULL AND "id" < $2 ORDER BY "id" DESC LIMIT 10`, sql)
+	assert.Equal(t, []any{"Fulano", 5}, args)
+}
+
+func TestBuildSearchSort(t *testing.T) {
+	query := repository.Query{Sort: []repository.Sort{{Field: "first_name", Desc: true}}, IncludeDeleted: true}
+	sql, args, err := buildSearch(questionDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `deleted_at`,`first_name`,`id` FROM `users` ORDER BY `first_name` IS NULL,`first_name` DESC,`id`", sql)
+	assert.Len(t, args, 0)
+
+	// the cursor compares the sort fields and the primary key as a tuple
+	query.Cursor = &repository.Cursor{Key: 5, Values: []any{"Fulano"}}
+	sql, args, err = buildSearch(questionDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `deleted_at`,`first_name`,`id` FROM `users` WHERE ((`first_name` < ? OR `first_name` IS NULL) OR (`first_name` = ? AND `id` > ?)) ORDER BY `first_name` IS NULL,`first_name` DESC,`id`", sql)
+	assert.Equal(t, []any{"Fulano", "Fulano", 5}, args)
+
+	// before the cursor, the order is reversed
+	query.Cursor = &repository.Cursor{Key: 5, Values: []any{nil}, Before: true}
+	sql, args, err = buildSearch(questionDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `deleted_at`,`first_name`,`id` FROM `users` WHERE (`first_name` IS NOT NULL OR (`first_name` IS NULL AND `id` < ?)) ORDER BY `first_name` IS NULL DESC,`first_name`,`id` DESC", sql)
+	assert.Equal(t, []any{5}, args)
+
+	// the cursor must have a value for each sort field
+	query.Cursor = &repository.Cursor{Key: 5}
+	_, _, err = buildSearch(questionDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	assert.Error(t, err)
+}
+
+func TestBuildSearchFilters(t *testing.T) {
+	query := repository.Query{
+		Where: map[string][]any{"first_name": {"Fulano"}},
+		Filters: []repository.Filter{
+			{Field: "id", Op: resource.OpGte, Values: []any{10}},
+			{Field: "id", Op: resource.OpNin, Values: []any{12, 13}},
+			{Field: "first_name", Op: resource.OpLike, Values: []any{"ful%"}},
+			{Field: "deleted_at", Op: resource.OpNull, Values: []any{false}},
+		},
+		IncludeDeleted: true,
+	}
+	sql, args, err := buildSearch(dollarDialect{}, &testResource, testResource.GetFieldNames(), query, false)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "deleted_at","first_name","id" FROM "users" WHERE "first_name" = $1 AND "id" >= $2 AND "id" NOT IN ($3,$4) AND LOWER("first_name") LIKE LOWER($5) AND "deleted_at" IS NOT NULL ORDER BY "id"`, sql)
+	assert.Equal(t, []any{"Fulano", 10, 12, 13, "ful%"}, args)
+}
+
+func TestBuildSearchExpr(t *testing.T) {
+	query := repository.Query{
+		Filters: []repository.Filter{{Field: "id", Op: resource.OpGt, Values: []any{1}}},
+		Expr: repository.Or{
+			repository.Filter{Field: "first_name", Op: resource.OpLike, Values: []any{"a%"}},
+			repository.Not{Expr: repository.And{
+				repository.Filter{Field: "id", Op: resource.OpIn, Values: []any{2, 3}},
+				repository.Filter{Field: "first_name", Op: resource.OpNull, Values: []any{true}},
+			}},
+		},
+	}
+	sql, args, err := buildSearch(questionDialect{}, &testResource, []string{"id"}, query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE `id` > ? AND (LOWER(`first_name`) LIKE LOWER(?) OR NOT ((`id` IN (?,?) AND `first_name` IS NULL))) AND `deleted_at` IS NULL ORDER BY `id`", sql)
+	assert.Equal(t, []any{1, "a%", 2, 3}, args)
+
+	// the keys of related expressions are selected by a subquery
+	groups := resource.Resource{Name: "user_groups", PrimaryKey: "id", Fields: map[string]resource.Field{"id": {}, "user_id": {}, "group_id": {}}}
+	query = repository.Query{Expr: repository.Related{Field: "id", Resource: &groups, Key: "user_id", Where: map[string][]any{"group_id": {7}}}, IncludeDeleted: true}
+	sql, args, err = buildSearch(dollarDialect{}, &testResource, []string{"id"}, query, false)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id" FROM "users" WHERE "id" IN (SELECT "user_id" FROM "user_groups" WHERE "group_id" = $1 AND "user_id" IS NOT NULL) ORDER BY "id"`, sql)
+	assert.Equal(t, []any{7}, args)
+}
+
+// castDialect converts the compared columns and values
+type castDialect struct{ questionDialect }
+
+func (castDialect) Compared(expr string, t resource.Type) string { return "CAST(" + expr + ")" }
+
+func TestBuildSearchComparisonDialect(t *testing.T) {
+	query := repository.Query{
+		Filters: []repository.Filter{{Field: "id", Op: resource.OpIn, Values: []any{1, 2}}},
+		Sort:    []repository.Sort{{Field: "first_name"}},
+		Cursor:  &repository.Cursor{Key: 5, Values: []any{"Fulano"}},
+	}
+	sql, _, err := buildSearch(castDialect{}, &testResource, []string{"id"}, query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE CAST(`id`) IN (CAST(?),CAST(?)) AND `deleted_at` IS NULL AND (CAST(`first_name`) > CAST(?) OR (CAST(`first_name`) = CAST(?) AND CAST(`id`) > CAST(?))) ORDER BY `first_name` IS NULL DESC,CAST(`first_name`),CAST(`id`)", sql)
+}
+
+// matchDialect matches the full-text indexes
+type matchDialect struct{ questionDialect }
+
+func (matchDialect) ColumnType(t resource.Type, key bool) string { return "text" }
+func (matchDialect) AutoIncrementColumn() string                 { return "serial" }
+func (matchDialect) Columns(ctx context.Context, db *sql.DB, table string) ([]Column, error) {
+	return nil, nil
+}
+func (matchDialect) Indexes(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	return nil, nil
+}
+func (matchDialect) CreateFullTextIndex(name, table string, columns []string) string { return "" }
+func (matchDialect) IndexColumns(ctx context.Context, db Querier, table, index string) ([]string, error) {
+	return nil, nil
+}
+func (matchDialect) Match(columns, placeholder string) string {
+	return "MATCH(" + columns + "," + placeholder + ")"
+}
+func (matchDialect) MatchTerms(terms []string) string { return strings.Join(terms, "+") }
+
+// wordsDialect splits the columns into words
+type wordsDialect struct {
+	questionDialect
+}
+
+func (wordsDialect) Words(column string) string { return "WORDS(" + column + ")" }
+
+func TestBuildSearchText(t *testing.T) {
+	users := testResource
+	users.Fields = map[string]resource.Field{"id": {}, "first_name": {FullText: true}, "last_name": {FullText: true}, "deleted_at": {}}
+	query := repository.Query{Text: "Ana  SILVA!", IncludeDeleted: true}
+
+	sql, args, err := buildSearch(questionDialect{}, &users, []string{"id"}, query, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE (LOWER(`first_name`) LIKE ? OR LOWER(`first_name`) LIKE ? OR LOWER(`last_name`) LIKE ? OR LOWER(`last_name`) LIKE ?) AND (LOWER(`first_name`) LIKE ? OR LOWER(`first_name`) LIKE ? OR LOWER(`last_name`) LIKE ? OR LOWER(`last_name`) LIKE ?) ORDER BY `id`", sql)
+	assert.Equal(t, []any{"ana%", "% ana%", "ana%", "% ana%", "silva%", "% silva%", "silva%", "% silva%"}, args)
+
+	// the dialect splits the columns into words
+	sql, _, err = buildSearch(wordsDialect{}, &users, []string{"id"}, repository.Query{Text: "ana", IncludeDeleted: true}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE (WORDS(`first_name`) LIKE ? OR WORDS(`first_name`) LIKE ? OR WORDS(`last_name`) LIKE ? OR WORDS(`last_name`) LIKE ?) ORDER BY `id`", sql)
+
+	sql, args, err = buildSearch(matchDialect{}, &users, []string{"id"}, query, false)
+	require.NoError(t, err)
+	assert.Contains(t, sql, "LIKE")
+
+	sql, args, err = buildSearch(matchDialect{}, &users, []string{"id"}, query, true)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE MATCH(`first_name`,`last_name`,?) ORDER BY `id`", sql)
+	assert.Equal(t, []any{"ana+silva"}, args)
+
+	sql, args = buildCount(matchDialect{}, &users, repository.Query{Text: " ? "}, true)
+	assert.Equal(t, "SELECT COUNT(*) FROM `users` WHERE `deleted_at` IS NULL", sql)
+	assert.Empty(t, args)
+}
+
+func TestBuildCount(t *testing.T) {
+	query := repository.Query{Where: map[string][]any{"first_name": {"Fulano"}}, Limit: 10, Cursor: &repository.Cursor{Key: 5}}
+	sql, args := buildCount(questionDialect{}, &testResource, query, false)
+	assert.Equal(t, "SELECT COUNT(*) FROM `users` WHERE `first_name` = ? AND `deleted_at` IS NULL", sql)
+	assert.Equal(t, []any{"Fulano"}, args)
+
+	sql, args = buildCount(dollarDialect{}, &testResource, repository.Query{IncludeDeleted: true}, false)
+	assert.Equal(t, `SELECT COUNT(*) FROM "users"`, sql)
+	assert.Len(t, args, 0)
+}
+
+func TestBuildAggregate(t *testing.T) {
+	query := repository.AggregateQuery{
+		Query:   repository.Query{Where: map[string][]any{"first_name": {"Fulano"}}, Limit: 10},
+		GroupBy: []string{"first_name"},
+		Aggregates: []repository.Aggregate{
+			{Func: repository.AggCount, Field: repository.AllRows},
+			{Func: repository.AggSum, Field: "id"},
+		},
+	}
+	sql, args := buildAggregate(questionDialect{}, &testResource, query, false)
+	assert.Equal(t, "SELECT `first_name`,COUNT(*) AS `count`,SUM(`id`) AS `sum_id` FROM `users` WHERE `first_name` = ? AND `deleted_at` IS NULL GROUP BY `first_name` ORDER BY `first_name` IS NULL DESC,`first_name`", sql)
+	assert.Equal(t, []any{"Fulano"}, args)
+
+	// without group fields, the rows are not grouped nor ordered
+	query = repository.AggregateQuery{
+		Query:      repository.Query{IncludeDeleted: true},
+		Aggregates: []repository.Aggregate{{Func: repository.AggMax, Field: "first_name"}},
+	}
+	sql, args = buildAggregate(dollarDialect{}, &testResource, query, false)
+	assert.Equal(t, `SELECT MAX("first_name") AS "max_first_name" FROM "users"`, sql)
+	assert.Len(t, args, 0)
+}
+
+func TestBuildInsert(t *testing.T) {
+	data := map[string]any{"id": 10, "first_name": "Fulano", "deleted_at": nil}
+	sql, args := buildInsert(questionDialect{}, &testResource, data)
+	assert.Equal(t, "INSERT INTO `users` (`deleted_at`,`first_name`) VALUES (?,?)", sql)
+	assert.Equal(t, []any{nil, "Fulano"}, args)
+
+	sql, args = buildInsert(dollarDialect{}, &testResource, data)
+	assert.Equal(t, `INSERT INTO "users" ("deleted_at","first_name") VALUES ($1,$2) RETURNING "id"`, sql)
+	assert.Equal(t, []any{nil, "Fulano"}, args)
+
+	// the primary key is inserted if it is not auto incremental
+	r := testResource
+	r.AutoIncrementalPK = false
+	sql, args = buildInsert(dollarDialect{}, &r, data)
+	assert.Equal(t, `INSERT INTO "users" ("deleted_at","first_name","id") VALUES ($1,$2,$3)`, sql)
+	assert.Equal(t, []any{nil, "Fulano", 10}, args)
+}
+
+func TestBuildUpdate(t *testing.T) {
+	data := map[string]any{"id": 10, "first_name": "Fulano"}
+	sql, args := buildUpdate(questionDialect{}, &testResource, data)
+	assert.Equal(t, "UPDATE `users` SET `first_name` = ?,`id` = ? WHERE `id` = ? AND `deleted_at` IS NULL", sql)
+	assert.Equal(t, []any{"Fulano", 10, 10}, args)
+
+	sql, args = buildUpdate(dollarDialect{}, &testResource, data)
+	assert.Equal(t, `UPDATE "users" SET "first_name" = $1,"id" = $2 WHERE "id" = $3 AND "deleted_at" IS NULL`, sql)
+	assert.Equal(t, []any{"Fulano", 10, 10}, args)
+}
+
+func TestBuildDelete(t *testing.T) {
+	sql, args := buildDelete(questionDialect{}, &testResource, 10)
+	assert.Equal(t, "UPDATE `users` SET `deleted_at` = NOW() WHERE `id` = ? AND `deleted_at` IS NULL", sql)
+	assert.Equal(t, []any{10}, args)
+
+	r := testResource
+	r.SoftDeleteField = null.String{}
+	sql, args = buildDelete(dollarDialect{}, &r, 10)
+	assert.Equal(t, `DELETE FROM "users" WHERE "id" = $1`, sql)
+	assert.Equal(t, []any{10}, args)
+}
+
+func TestBuildRestoreAndPurge(t *testing.T) {
+	sql, args := buildRestore(questionDialect{}, &testResource, 10)
+	assert.Equal(t, "UPDATE `users` SET `deleted_at` = NULL WHERE `id` = ? AND `deleted_at` IS NOT NULL", sql)
+	assert.Equal(t, []any{10}, args)
+
+	sql, args = buildPurge(dollarDialect{}, &testResource, 10)
+	assert.Equal(t, `DELETE FROM "users" WHERE "id" = $1 AND "deleted_at" IS NOT NULL`, sql)
+	assert.Equal(t, []any{10}, args)
+}
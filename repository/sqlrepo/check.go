@@ -0,0 +1,102 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Compile-time check that Repository implements the SchemaChecker interface
+var _ repository.SchemaChecker = (*Repository)(nil)
+
+// CheckSchema compares the resources with their tables, read from the catalog of the database.
+// The dialect of the repository must be a SchemaDialect and the repository can not be bound to a transaction.
+func (r Repository) CheckSchema(ctx context.Context, resources ...*resource.Resource) (repository.SchemaReport, error) {
+	d, ok := r.dialect.(SchemaDialect)
+	if !ok {
+		return repository.SchemaReport{}, errors.New("dialect can not read the schema of the database")
+	}
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return repository.SchemaReport{}, errors.New("schema can not be checked in a transaction")
+	}
+	return NewMigrator(db, d).CheckSchema(ctx, resources...)
+}
+
+// CheckSchema compares the fields, primary key and timestamp and soft delete fields of
+// the resources with the columns of their tables, returning the drifts found.
+// Columns of the tables that are not fields of the resources are not drifts.
+func (m *Migrator) CheckSchema(ctx context.Context, resources ...*resource.Resource) (repository.SchemaReport, error) {
+	report := repository.SchemaReport{Drifts: make([]repository.Drift, 0)}
+	for _, b := range resources {
+		columns, err := m.dialect.Columns(ctx, m.db, b.Table())
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", b.Table(), err)
+		}
+		report.Drifts = append(report.Drifts, drifts(b, columns)...)
+	}
+	return report, nil
+}
+
+// drifts returns the differences between a resource and the columns of its table
+func drifts(b *resource.Resource, columns []Column) []repository.Drift {
+	result := make([]repository.Drift, 0)
+	drift := func(kind repository.DriftKind, column, msg string, args ...any) {
+		result = append(result, repository.Drift{
+			Resource: b.Name,
+			Table:    b.Table(),
+			Kind:     kind,
+			Column:   column,
+			Message:  fmt.Sprintf(msg, args...),
+		})
+	}
+	if len(columns) == 0 {
+		drift(repository.DriftMissingTable, "", "table %s does not exist", b.Table())
+		return result
+	}
+
+	existing := make(map[string]Column, len(columns))
+	primaryKeys := make([]string, 0, 1)
+	for _, c := range columns {
+		existing[c.Name] = c
+		if c.PrimaryKey {
+			primaryKeys = append(primaryKeys, c.Name)
+		}
+	}
+
+	// the fields are checked with the role they have in the resource
+	roles := make(map[string]string, len(b.Fields)+4)
+	for field := range b.Fields {
+		roles[field] = "field"
+	}
+	for _, f := range []struct {
+		field null.String
+		role  string
+	}{
+		{b.CreatedAtField, "created at field"},
+		{b.UpdatedAtField, "updated at field"},
+		{b.SoftDeleteField, "soft delete field"},
+	} {
+		if f.field.Valid {
+			roles[f.field.String] = f.role
+		}
+	}
+	roles[b.PrimaryKey] = "primary key"
+	for _, field := range sortedKeys(roles) {
+		if _, ok := existing[field]; !ok {
+			drift(repository.DriftMissingColumn, field, "%s %s is not a column of table %s", roles[field], field, b.Table())
+		}
+	}
+
+	sort.Strings(primaryKeys)
+	if _, ok := existing[b.PrimaryKey]; ok && (len(primaryKeys) != 1 || primaryKeys[0] != b.PrimaryKey) {
+		drift(repository.DriftPrimaryKey, b.PrimaryKey, "primary key %s does not match the primary key %v of table %s", b.PrimaryKey, primaryKeys, b.Table())
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package sqlrepo
+
+import (
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	null "gopkg.in/guregu/null.v3"
+)
+
+func TestDrifts(t *testing.T) {
+	users := resource.Resource{
+		Name:       "users",
+		PrimaryKey: "uuid",
+		Fields: map[string]resource.Field{
+			"uuid":       {},
+			"first_name": {},
+		},
+		CreatedAtField:  null.StringFrom("created_at"),
+		SoftDeleteField: null.StringFrom("deleted_at"),
+	}
+
+	assert.Empty(t, drifts(&users, []Column{
+		{Name: "uuid", PrimaryKey: true},
+		{Name: "first_name"},
+		{Name: "created_at"},
+		{Name: "deleted_at"},
+		{Name: "unmapped"},
+	}))
+
+	assert.Equal(t, []repository.Drift{
+		{Resource: "users", Table: "users", Kind: repository.DriftMissingColumn, Column: "deleted_at", Message: "soft delete field deleted_at is not a column of table users"},
+		{Resource: "users", Table: "users", Kind: repository.DriftMissingColumn, Column: "first_name", Message: "field first_name is not a column of table users"},
+		{Resource: "users", Table: "users", Kind: repository.DriftPrimaryKey, Column: "uuid", Message: "primary key uuid does not match the primary key [id] of table users"},
+	}, drifts(&users, []Column{
+		{Name: "id", PrimaryKey: true},
+		{Name: "uuid"},
+		{Name: "name"},
+		{Name: "created_at"},
+	}))
+
+	assert.Equal(t, []repository.Drift{
+		{Resource: "users", Table: "users", Kind: repository.DriftMissingTable, Message: "table users does not exist"},
+	}, drifts(&users, nil))
+}
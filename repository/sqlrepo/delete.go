@@ -0,0 +1,30 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+func (r Repository) Delete(ctx context.Context, b *resource.Resource, id any) error {
+	sqlStr, args := buildDelete(r.dialect, b, id)
+	return r.execOne(ctx, sqlStr, args)
+}
+
+// execOne runs a statement that changes a single row, returning ErrNotFound if no row was affected
+func (r Repository) execOne(ctx context.Context, sqlStr string, args []any) error {
+	result, err := r.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return r.dialect.TranslateError(err)
+	}
+	affect, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affect == 0 {
+		return fmt.Errorf("%w: no rows affected", repository.ErrNotFound)
+	}
+	return nil
+}
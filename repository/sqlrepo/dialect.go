@@ -0,0 +1,75 @@
+package sqlrepo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Dialect describes the parts of the SQL syntax that differ between databases.
+// Each database/sql backend implements it and shares the query builder of this package.
+type Dialect interface {
+	// Placeholder returns the bind parameter of the n-th argument of a statement, starting at 1
+	Placeholder(n int) string
+	// Quote quotes an identifier, such as a table or column name
+	Quote(identifier string) string
+	// CurrentTimestamp returns the expression that evaluates to the current timestamp
+	CurrentTimestamp() string
+	// Limit returns the clause that paginates the rows of a select statement.
+	// A limit lower or equal to 0 means no limit.
+	Limit(limit, offset int) string
+	// Returning returns the clause appended to an insert statement to read the
+	// generated primary key, or an empty string if the driver supports sql.Result.LastInsertId
+	Returning(pk string) string
+	// TranslateError converts an error of the driver to one of the repository errors,
+	// such as repository.ErrConflict for a duplicated key, wrapped with repository.NewError.
+	// Errors that are not known are returned as they are.
+	TranslateError(err error) error
+}
+
+// ComparisonDialect is implemented by the dialects that store the values of some types
+// in columns that do not compare as the type, such as decimals stored as text.
+// The query builder converts the columns and values of the conditions and sorts with it.
+type ComparisonDialect interface {
+	Dialect
+	// Compared returns the expression, a column or a bound value, converted
+	// so it compares as the type
+	Compared(expr string, t resource.Type) string
+}
+
+// FullTextDialect is implemented by the dialects of databases with full-text indexes.
+// The migrations create an index of the full-text fields of each resource, which
+// the full-text search matches once it exists, instead of like patterns.
+type FullTextDialect interface {
+	SchemaDialect
+	// CreateFullTextIndex returns the statement that creates a full-text index of the columns of a table
+	CreateFullTextIndex(name, table string, columns []string) string
+	// IndexColumns returns the columns of an index of a table, in order, or none if it does not exist
+	IndexColumns(ctx context.Context, db Querier, table, index string) ([]string, error)
+	// Match returns the condition matching the quoted columns, joined with commas,
+	// against the terms of MatchTerms, bound to placeholder
+	Match(columns, placeholder string) string
+	// MatchTerms returns the argument matching the rows that have words starting with every term
+	MatchTerms(terms []string) string
+}
+
+// TextDialect is implemented by the dialects that can split the values of a column into words
+// as repository.TextTerms, so the full-text search without an index matches the same rows as
+// the other repositories. Without it, words are only separated by spaces.
+type TextDialect interface {
+	Dialect
+	// Words returns the expression of the lower case words of the quoted column, separated by spaces
+	Words(column string) string
+}
+
+// QuoteIdentifier quotes the identifier with the given quote character,
+// escaping it by doubling when it is part of the name.
+// Dotted names (schema.table) have each part quoted.
+func QuoteIdentifier(identifier string, quote string) string {
+	parts := strings.Split(identifier, ".")
+	for i, p := range parts {
+		parts[i] = quote + strings.ReplaceAll(p, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
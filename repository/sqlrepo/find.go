@@ -0,0 +1,34 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+func (r Repository) Find(ctx context.Context, b *resource.Resource, id any, opts repository.FindOptions) (map[string]any, error) {
+	columns, err := repository.Columns(b, opts.Fields)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, args := buildFind(r.dialect, b, columns, id, opts)
+	response := r.db.QueryRowContext(ctx, sqlStr, args...)
+
+	values := make([]any, len(columns))
+	scanArgs := make([]any, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	err = response.Scan(scanArgs...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return make(map[string]any, 0), nil
+		}
+		return make(map[string]any, 0), r.dialect.TranslateError(err)
+	}
+
+	return r.parseRow(b, columns, values)
+}
@@ -0,0 +1,30 @@
+package sqlrepo
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+func (r Repository) Insert(ctx context.Context, b *resource.Resource, data map[string]any) (int64, error) {
+	sqlStr, args := buildInsert(r.dialect, b, data)
+
+	// databases without LastInsertId return the generated key from the statement
+	if b.AutoIncrementalPK && r.dialect.Returning(b.PrimaryKey) != "" {
+		var id int64
+		err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id)
+		if err != nil {
+			return 0, r.dialect.TranslateError(err)
+		}
+		return id, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return 0, r.dialect.TranslateError(err)
+	}
+	if b.AutoIncrementalPK {
+		return result.LastInsertId()
+	}
+	return 0, nil
+}
@@ -0,0 +1,99 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Migrator creates and migrates the tables of resources. It only adds what is missing:
+// tables, columns and indexes, so columns and data are never dropped.
+type Migrator struct {
+	db      *sql.DB
+	dialect SchemaDialect
+	// DryRun makes AutoMigrate write the statements to Output instead of running them
+	DryRun bool
+	// Output is where the statements are written in dry run mode, os.Stdout if nil
+	Output io.Writer
+}
+
+// NewMigrator returns a new Migrator that reads the schema of the database with the given dialect
+func NewMigrator(db *sql.DB, dialect SchemaDialect) *Migrator {
+	return &Migrator{db: db, dialect: dialect}
+}
+
+// Plan returns the statements that migrate the database to the resources, without running them
+func (m *Migrator) Plan(ctx context.Context, resources ...*resource.Resource) ([]string, error) {
+	statements := make([]string, 0)
+	for _, b := range resources {
+		columns, err := m.dialect.Columns(ctx, m.db, b.Table())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Table(), err)
+		}
+		if len(columns) == 0 {
+			statements = append(statements, CreateTable(m.dialect, b)...)
+			continue
+		}
+
+		existing := make(map[string]bool, len(columns))
+		for _, c := range columns {
+			existing[c.Name] = true
+		}
+		for _, field := range b.GetFieldNames() {
+			if !existing[field] {
+				statements = append(statements, addColumn(m.dialect, b, field))
+			}
+		}
+
+		indexes, err := m.dialect.Indexes(ctx, m.db, b.Table())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Table(), err)
+		}
+		existing = make(map[string]bool, len(indexes))
+		for _, index := range indexes {
+			existing[index] = true
+		}
+		for _, field := range b.GetFieldNames() {
+			if b.Fields[field].Index && field != b.PrimaryKey && !existing[indexName(b, field)] {
+				statements = append(statements, createIndex(m.dialect, b, field))
+			}
+		}
+		if stmt, ok := createFullTextIndex(m.dialect, b); ok && !existing[fullTextIndexName(b)] {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements, nil
+}
+
+// AutoMigrate creates the missing tables of the resources and adds their missing columns and indexes.
+// In dry run mode, the statements are written to the Output instead.
+func (m *Migrator) AutoMigrate(ctx context.Context, resources ...*resource.Resource) error {
+	statements, err := m.Plan(ctx, resources...)
+	if err != nil {
+		return err
+	}
+	if m.DryRun {
+		out := m.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		for _, stmt := range statements {
+			_, err = fmt.Fprintf(out, "%s;\n", stmt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, stmt := range statements {
+		_, err = m.db.ExecContext(ctx, stmt)
+		if err != nil {
+			return m.dialect.TranslateError(err)
+		}
+	}
+	return nil
+}
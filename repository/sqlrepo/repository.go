@@ -0,0 +1,116 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Repository is the implementation of the RepositoryInterface shared by the
+// database/sql backends. The syntax of each database is given by its Dialect.
+type Repository struct {
+	// db is the database, or the transaction the repository is bound to
+	db      Querier
+	dialect Dialect
+	// fullText caches the full-text indexes of the tables, shared with the transactions
+	fullText *fullTextIndexes
+}
+
+// NewRepository returns a new Repository that builds its statements with the given dialect
+func NewRepository(db *sql.DB, dialect Dialect) Repository {
+	return Repository{db: db, dialect: dialect, fullText: &fullTextIndexes{ttl: fullTextLookupInterval}}
+}
+
+// fullTextLookupInterval is how long a table is known not to have the full-text index
+// created by the migrations, before it is looked up again by the next search
+const fullTextLookupInterval = time.Minute
+
+// fullTextIndexes caches whether the tables have the full-text index created by the migrations.
+// Tables without it are looked up again after the ttl, so an index created later is used,
+// and a table is forgotten when a statement matching its index fails, in case it was dropped.
+type fullTextIndexes struct {
+	ttl    time.Duration
+	tables sync.Map
+}
+
+// fullTextLookup is the result of the lookup of the full-text index of a table
+type fullTextLookup struct {
+	indexed bool
+	at      time.Time
+}
+
+// fullTextIndexed returns true if the full-text search of the query can match the index
+// of the full-text fields of the resource, which the dialect must support
+func (r Repository) fullTextIndexed(ctx context.Context, b *resource.Resource, q repository.Query) (bool, error) {
+	d, ok := r.dialect.(FullTextDialect)
+	if !ok || r.fullText == nil || len(repository.TextTerms(q.Text)) == 0 {
+		return false, nil
+	}
+	if v, ok := r.fullText.tables.Load(b.Table()); ok {
+		lookup := v.(fullTextLookup)
+		if lookup.indexed || time.Since(lookup.at) < r.fullText.ttl {
+			return lookup.indexed, nil
+		}
+	}
+	// the lookup runs in the transaction of the repository, so it does not wait for another connection
+	columns, err := d.IndexColumns(ctx, r.db, b.Table(), fullTextIndexName(b))
+	if err != nil {
+		return false, r.dialect.TranslateError(err)
+	}
+	// the index is only matched if it has the current full-text fields
+	fields := b.FullTextFields()
+	indexed := len(columns) == len(fields)
+	for i := 0; indexed && i < len(fields); i++ {
+		indexed = columns[i] == fields[i]
+	}
+	r.fullText.tables.Store(b.Table(), fullTextLookup{indexed: indexed, at: time.Now()})
+	return indexed, nil
+}
+
+// fullTextFailed forgets the full-text index of the resource if the failed statement matched it,
+// so the next search looks it up again, and returns the error translated by the dialect
+func (r Repository) fullTextFailed(b *resource.Resource, index bool, err error) error {
+	if index {
+		r.fullText.tables.Delete(b.Table())
+	}
+	return r.dialect.TranslateError(err)
+}
+
+// Compile-time check that Repository implements the Repository interface
+var _ repository.RepositoryInterface = (*Repository)(nil)
+
+// parseRow parses a row from the database, returning a map with the field names
+// as keys and the values, converted to the types of the fields, as values
+func (r Repository) parseRow(b *resource.Resource, columns []string, values []any) (map[string]any, error) {
+	result := make(map[string]any, len(columns))
+	for i, v := range values {
+		result[columns[i]] = v
+	}
+	return b.ScanRow(result)
+}
+
+// parseRows parses a row from the database, returning a map with the field names as keys and the values as values
+func (r Repository) parseRows(b *resource.Resource, columns []string, rows *sql.Rows) ([]map[string]any, error) {
+	results := make([]map[string]any, 0)
+	for rows.Next() {
+		values := make([]any, len(columns))
+		scanArgs := make([]any, len(columns))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+		err := rows.Scan(scanArgs...)
+		if err != nil {
+			return make([]map[string]any, 0), err
+		}
+		result, err := r.parseRow(b, columns, values)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,57 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexDialect reads the columns of the full-text indexes from a shared table, counting the lookups
+type indexDialect struct {
+	matchDialect
+	columns *[]string
+	lookups *int
+}
+
+func (d indexDialect) IndexColumns(ctx context.Context, db Querier, table, index string) ([]string, error) {
+	*d.lookups++
+	return *d.columns, nil
+}
+
+func TestFullTextIndexed(t *testing.T) {
+	users := testResource
+	users.Fields = map[string]resource.Field{"id": {}, "first_name": {FullText: true}}
+	var columns []string
+	lookups := 0
+	r := Repository{dialect: indexDialect{columns: &columns, lookups: &lookups}, fullText: &fullTextIndexes{ttl: time.Hour}}
+	ctx := context.Background()
+	query := repository.Query{Text: "ana"}
+	indexed := func() bool {
+		indexed, err := r.fullTextIndexed(ctx, &users, query)
+		require.NoError(t, err)
+		return indexed
+	}
+
+	// tables without the index are only looked up again after the ttl, so an index created later is used
+	assert.False(t, indexed())
+	columns = []string{"first_name"}
+	assert.False(t, indexed())
+	assert.Equal(t, 1, lookups)
+	r.fullText.ttl = 0
+	assert.True(t, indexed())
+	assert.Equal(t, 2, lookups)
+
+	// tables with the index are only looked up again after a statement matching it fails
+	columns = nil
+	assert.True(t, indexed())
+	assert.Equal(t, 2, lookups)
+	_ = r.fullTextFailed(&users, true, errors.New("no index"))
+	assert.False(t, indexed())
+	assert.Equal(t, 3, lookups)
+}
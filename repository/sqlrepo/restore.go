@@ -0,0 +1,25 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+func (r Repository) Restore(ctx context.Context, b *resource.Resource, id any) error {
+	if !b.SoftDeleteField.Valid {
+		return fmt.Errorf("%w: resource has no soft delete field", repository.ErrInvalidQuery)
+	}
+	sqlStr, args := buildRestore(r.dialect, b, id)
+	return r.execOne(ctx, sqlStr, args)
+}
+
+func (r Repository) Purge(ctx context.Context, b *resource.Resource, id any) error {
+	if !b.SoftDeleteField.Valid {
+		return fmt.Errorf("%w: resource has no soft delete field", repository.ErrInvalidQuery)
+	}
+	sqlStr, args := buildPurge(r.dialect, b, id)
+	return r.execOne(ctx, sqlStr, args)
+}
@@ -0,0 +1,141 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// SchemaDialect is a Dialect that also knows the data definition syntax and the catalog
+// of its database, so the tables of the resources can be created and migrated.
+type SchemaDialect interface {
+	Dialect
+	// ColumnType returns the type of the column of a field type.
+	// key is true for primary keys and indexed columns, which some databases can only index with a bounded length.
+	ColumnType(t resource.Type, key bool) string
+	// AutoIncrementColumn returns the definition of an auto incremental primary key column
+	AutoIncrementColumn() string
+	// Columns returns the columns of a table, or none if the table does not exist
+	Columns(ctx context.Context, db *sql.DB, table string) ([]Column, error)
+	// Indexes returns the names of the indexes of a table
+	Indexes(ctx context.Context, db *sql.DB, table string) ([]string, error)
+}
+
+// Column is a column of a table, as read from the catalog of the database
+type Column struct {
+	Name string
+	// PrimaryKey is true if the column is part of the primary key
+	PrimaryKey bool
+}
+
+// CreateTable returns the statements that create the table of the resource and its indexes.
+// The primary key is the first column, followed by the other fields in alphabetical order.
+func CreateTable(d SchemaDialect, b *resource.Resource) []string {
+	q := newBuilder(d)
+	q.write(`CREATE TABLE `, q.ident(b.Table()), ` (`)
+	q.write(q.ident(b.PrimaryKey), ` `)
+	if b.AutoIncrementalPK {
+		q.write(d.AutoIncrementColumn())
+	} else {
+		q.write(d.ColumnType(b.Fields[b.PrimaryKey].Type, true), ` NOT NULL`)
+	}
+	for _, field := range b.GetFieldNames() {
+		if field == b.PrimaryKey {
+			continue
+		}
+		q.write(`, `, columnDefinition(d, b, field))
+	}
+	q.write(`, PRIMARY KEY (`, q.ident(b.PrimaryKey), `))`)
+	table, _ := q.build()
+
+	statements := []string{table}
+	for _, field := range b.GetFieldNames() {
+		if b.Fields[field].Index && field != b.PrimaryKey {
+			statements = append(statements, createIndex(d, b, field))
+		}
+	}
+	if stmt, ok := createFullTextIndex(d, b); ok {
+		statements = append(statements, stmt)
+	}
+	return statements
+}
+
+// createFullTextIndex returns the statement that creates the index of the full-text fields
+// of the resource, if it has any and the dialect implements FullTextDialect
+func createFullTextIndex(d SchemaDialect, b *resource.Resource) (string, bool) {
+	f, ok := d.(FullTextDialect)
+	fields := b.FullTextFields()
+	if !ok || len(fields) == 0 {
+		return "", false
+	}
+	return f.CreateFullTextIndex(fullTextIndexName(b), b.Table(), fields), true
+}
+
+// fullTextIndexName returns the name of the full-text index of a resource, such as ft_users
+func fullTextIndexName(b *resource.Resource) string {
+	return "ft_" + strings.ReplaceAll(b.Table(), ".", "_")
+}
+
+// addColumn returns the statement that adds the column of a field to the table of the resource
+func addColumn(d SchemaDialect, b *resource.Resource, field string) string {
+	return `ALTER TABLE ` + d.Quote(b.Table()) + ` ADD COLUMN ` + columnDefinition(d, b, field)
+}
+
+// createIndex returns the statement that creates the index of a field
+func createIndex(d SchemaDialect, b *resource.Resource, field string) string {
+	return `CREATE INDEX ` + d.Quote(indexName(b, field)) + ` ON ` + d.Quote(b.Table()) + ` (` + d.Quote(field) + `)`
+}
+
+// columnDefinition returns the definition of the column of a field that is not the primary key.
+// Columns are nullable, so they can be added to tables that already have rows.
+func columnDefinition(d SchemaDialect, b *resource.Resource, field string) string {
+	f := b.Fields[field]
+	return d.Quote(field) + ` ` + d.ColumnType(f.Type, f.Index)
+}
+
+// indexName returns the name of the index of a field, such as idx_users_email
+func indexName(b *resource.Resource, field string) string {
+	return "idx_" + strings.ReplaceAll(b.Table(), ".", "_") + "_" + field
+}
+
+// QueryStrings runs a query that returns a single string column, such as the names of
+// the indexes of a table, and returns its values
+func QueryStrings(ctx context.Context, db Querier, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := make([]string, 0)
+	for rows.Next() {
+		var v string
+		err = rows.Scan(&v)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// QueryColumns runs a query that returns the name of each column of a table
+// and whether it is part of the primary key, and returns the columns
+func QueryColumns(ctx context.Context, db *sql.DB, query string, args ...any) ([]Column, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := make([]Column, 0)
+	for rows.Next() {
+		var c Column
+		err = rows.Scan(&c.Name, &c.PrimaryKey)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
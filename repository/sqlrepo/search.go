@@ -0,0 +1,76 @@
+package sqlrepo
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+func (r Repository) Search(ctx context.Context, b *resource.Resource, q repository.Query) ([]map[string]any, error) {
+	columns, err := repository.Columns(b, q.Fields)
+	if err != nil {
+		return nil, err
+	}
+	err = validateQuery(b, q)
+	if err != nil {
+		return nil, err
+	}
+	index, err := r.fullTextIndexed(ctx, b, q)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, args, err := buildSearch(r.dialect, b, columns, q, index)
+	if err != nil {
+		return nil, repository.NewError(repository.ErrInvalidQuery, err)
+	}
+	response, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, r.fullTextFailed(b, index, err)
+	}
+	defer response.Close()
+	rows, err := r.parseRows(b, columns, response)
+	if err != nil {
+		return nil, err
+	}
+
+	// rows before a cursor are selected in the reverse order
+	if q.Cursor != nil && q.Cursor.Before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	return rows, nil
+}
+
+func (r Repository) Count(ctx context.Context, b *resource.Resource, q repository.Query) (int64, error) {
+	err := validateQuery(b, q)
+	if err != nil {
+		return 0, err
+	}
+	index, err := r.fullTextIndexed(ctx, b, q)
+	if err != nil {
+		return 0, err
+	}
+	sqlStr, args := buildCount(r.dialect, b, q, index)
+	var count int64
+	err = r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&count)
+	if err != nil {
+		return 0, r.fullTextFailed(b, index, err)
+	}
+	return count, nil
+}
+
+// validateQuery validates the filters, the expression and the full-text search of the query,
+// which are written to the statements
+func validateQuery(b *resource.Resource, q repository.Query) error {
+	err := repository.ValidateFilters(b, q.Filters)
+	if err != nil {
+		return err
+	}
+	err = repository.ValidateExpr(b, q.Expr)
+	if err != nil {
+		return err
+	}
+	return repository.ValidateText(b, q.Text)
+}
@@ -0,0 +1,106 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// Querier is the part of the database/sql api used by the repository and the dialects,
+// implemented by both *sql.DB and *sql.Tx
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Compile-time check that Repository implements the Transactional and ReadTransactional interfaces
+var _ repository.Transactional = (*Repository)(nil)
+var _ repository.ReadTransactional = Repository{}
+
+// errReadOnly is returned by the writes of read-only transactions
+var errReadOnly = fmt.Errorf("%w: read-only transaction", repository.ErrInvalidQuery)
+
+// WithTx runs fn in a database transaction, passing a repository bound to it.
+// If the repository is already bound to a transaction, fn runs in it.
+func (r Repository) WithTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return fn(r)
+	}
+	return withTx(ctx, db, nil, func(tx *sql.Tx) error {
+		return fn(Repository{db: tx, dialect: r.dialect, fullText: r.fullText})
+	})
+}
+
+// WithReadTx runs fn in a read-only database transaction, passing a repository bound to it
+// whose writes fail, since not every driver enforces read-only transactions.
+// If the repository is already bound to a transaction, fn runs in it.
+func (r Repository) WithReadTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return fn(r)
+	}
+	return withTx(ctx, db, &sql.TxOptions{ReadOnly: true}, func(tx *sql.Tx) error {
+		return fn(readTx{Repository{db: tx, dialect: r.dialect, fullText: r.fullText}})
+	})
+}
+
+// withTx runs fn in a transaction of the database with the options,
+// committed if fn returns nil and rolled back otherwise
+func withTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// readTx is the repository of a read-only transaction, which reads the tables
+// of the transaction and whose writes fail
+type readTx struct {
+	Repository
+}
+
+func (tx readTx) Insert(ctx context.Context, b *resource.Resource, data map[string]any) (int64, error) {
+	return 0, errReadOnly
+}
+
+func (tx readTx) Update(ctx context.Context, b *resource.Resource, data map[string]any) (bool, error) {
+	return false, errReadOnly
+}
+
+func (tx readTx) Delete(ctx context.Context, b *resource.Resource, id any) error {
+	return errReadOnly
+}
+
+func (tx readTx) Restore(ctx context.Context, b *resource.Resource, id any) error {
+	return errReadOnly
+}
+
+func (tx readTx) Purge(ctx context.Context, b *resource.Resource, id any) error {
+	return errReadOnly
+}
+
+func (tx readTx) WithTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	return fn(tx)
+}
+
+func (tx readTx) WithReadTx(ctx context.Context, fn func(tx repository.RepositoryInterface) error) error {
+	return fn(tx)
+}
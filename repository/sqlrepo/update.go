@@ -0,0 +1,26 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+func (r Repository) Update(ctx context.Context, b *resource.Resource, data map[string]any) (bool, error) {
+	if _, ok := data[b.PrimaryKey]; !ok {
+		return false, fmt.Errorf("%w: primary key not in data", repository.ErrInvalidQuery)
+	}
+
+	sqlStr, args := buildUpdate(r.dialect, b, data)
+	result, err := r.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return false, r.dialect.TranslateError(err)
+	}
+	affect, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affect > 0, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// MaxTextTerms is the maximum number of terms of a full-text search
+const MaxTextTerms = 16
+
+// TextTerms splits a full-text search into its terms: the lower case words of the text,
+// without duplicates. A row matches the search if every term starts a word of one of
+// the full-text fields of the resource, where words are the runs of letters and digits.
+func TextTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// ValidateText returns an error if the full-text search has too many terms,
+// or if the resource has no full-text fields to match them
+func ValidateText(b *resource.Resource, text string) error {
+	terms := TextTerms(text)
+	if len(terms) == 0 {
+		return nil
+	}
+	if len(terms) > MaxTextTerms {
+		return NewError(ErrInvalidQuery, fmt.Errorf("full-text search with more than %d terms", MaxTextTerms))
+	}
+	if len(b.FullTextFields()) == 0 {
+		return NewError(ErrInvalidQuery, errors.New("resource without full-text fields"))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package resource
+
+import "fmt"
+
+// Operator is a comparison of the values of a field with the values of a search filter
+type Operator string
+
+const (
+	// OpEq matches values equal to the value of the filter
+	OpEq Operator = "eq"
+	// OpNe matches values not equal to the value of the filter
+	OpNe Operator = "ne"
+	// OpGt matches values greater than the value of the filter
+	OpGt Operator = "gt"
+	// OpGte matches values greater than or equal to the value of the filter
+	OpGte Operator = "gte"
+	// OpLt matches values lower than the value of the filter
+	OpLt Operator = "lt"
+	// OpLte matches values lower than or equal to the value of the filter
+	OpLte Operator = "lte"
+	// OpLike matches strings against a pattern, where % is any sequence of characters
+	// and _ is any single character, ignoring case
+	OpLike Operator = "like"
+	// OpIn matches values equal to one of the values of the filter
+	OpIn Operator = "in"
+	// OpNin matches values not equal to any of the values of the filter
+	OpNin Operator = "nin"
+	// OpNull matches null values if the value of the filter is true, and not null values if false
+	OpNull Operator = "null"
+)
+
+// Valid returns true if the operator is one of the known operators
+func (o Operator) Valid() bool {
+	switch o {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpLike, OpIn, OpNin, OpNull:
+		return true
+	}
+	return false
+}
+
+// AppliesTo returns true if the operator can compare the values of the type:
+// bool and json values are not ordered, and only strings match patterns
+func (o Operator) AppliesTo(t Type) bool {
+	switch o {
+	case OpGt, OpGte, OpLt, OpLte:
+		return t != TypeBool && t != TypeJSON
+	case OpLike:
+		return t == TypeString || t == TypeAny
+	}
+	return true
+}
+
+// validateOperators returns an error if an operator of a field is not
+// known or can not compare the values of the field
+func validateOperators(name string, field Field) error {
+	for _, op := range field.Operators {
+		if !op.Valid() {
+			return fmt.Errorf("field %s has invalid operator: %s", name, op)
+		}
+		if !op.AppliesTo(field.Type) {
+			return fmt.Errorf("field %s has operator %s, which does not apply to type %s", name, op, field.Type)
+		}
+	}
+	return nil
+}
+
+// validateFullText returns an error if a full-text field is not a string
+func validateFullText(name string, field Field) error {
+	if field.FullText && field.Type != TypeString && field.Type != TypeAny {
+		return fmt.Errorf("field %s is full-text, which does not apply to type %s", name, field.Type)
+	}
+	return nil
+}
+
+// validateHidden returns an error if a hidden field can be sorted, matched by the q param
+// or aggregated, since its values would be returned in the cursors, highlights and groups
+func validateHidden(name string, field Field) error {
+	if field.Hidden && (field.Sortable || field.FullText || field.Aggregatable) {
+		return fmt.Errorf("field %s is hidden, so it can not be sortable, full-text or aggregatable", name)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowsOperator(t *testing.T) {
+	b := Resource{
+		Name:       "cars",
+		PrimaryKey: "id",
+		Fields: map[string]Field{
+			"id":    {Type: TypeInt},
+			"year":  {Type: TypeInt, Operators: []Operator{OpGte, OpLte}},
+			"plate": {Type: TypeString, Unsearchable: true, Operators: []Operator{OpLike}},
+		},
+	}
+	assert.True(t, b.AllowsOperator("id", OpEq))
+	assert.False(t, b.AllowsOperator("id", OpGt))
+	assert.True(t, b.AllowsOperator("year", OpGte))
+	assert.False(t, b.AllowsOperator("year", OpLike))
+	assert.False(t, b.AllowsOperator("plate", OpLike))
+	assert.False(t, b.AllowsOperator("missing", OpEq))
+}
+
+func TestFromStructOperators(t *testing.T) {
+	type car struct {
+		ID    int64  `json:"id" pk:"true"`
+		Year  int64  `json:"year" operators:"gte, lte"`
+		Plate string `json:"plate" operators:"like"`
+	}
+	var b Resource
+	require.NoError(t, b.FromStruct(car{}))
+	assert.Equal(t, []Operator{OpGte, OpLte}, b.Fields["year"].Operators)
+	assert.Equal(t, []Operator{OpLike}, b.Fields["plate"].Operators)
+
+	type invalid struct {
+		Year int64 `json:"year" operators:"like"`
+	}
+	assert.EqualError(t, b.FromStruct(invalid{}), "field year has operator like, which does not apply to type int")
+
+	type unknown struct {
+		Year int64 `json:"year" operators:"between"`
+	}
+	assert.EqualError(t, b.FromStruct(unknown{}), "field year has invalid operator: between")
+}
+
+func TestFromStructFullText(t *testing.T) {
+	type user struct {
+		ID       int64  `json:"id" pk:"true"`
+		First    string `json:"first_name" full_text:"true"`
+		Last     string `json:"last_name" full_text:""`
+		Document string `json:"document"`
+	}
+	var b Resource
+	assert.NoError(t, b.FromStruct(user{}))
+	assert.Equal(t, []string{"first_name", "last_name"}, b.FullTextFields())
+
+	type invalid struct {
+		Age int64 `json:"age" full_text:"true"`
+	}
+	assert.EqualError(t, b.FromStruct(invalid{}), "field age is full-text, which does not apply to type int")
+}
+
+func TestFromStructAggregatable(t *testing.T) {
+	type rent struct {
+		ID        int64   `json:"id" pk:"true"`
+		VehicleID int64   `json:"vehicle_id" aggregatable:"true"`
+		Hours     float64 `json:"hours" aggregatable:""`
+		Plate     string  `json:"plate"`
+	}
+	var b Resource
+	assert.NoError(t, b.FromStruct(rent{}))
+	assert.True(t, b.IsAggregatable("vehicle_id"))
+	assert.True(t, b.IsAggregatable("hours"))
+	assert.False(t, b.IsAggregatable("plate"))
+	assert.True(t, b.HasAggregatableFields())
+
+	b = Resource{Fields: map[string]Field{"id": {}}}
+	assert.False(t, b.HasAggregatableFields())
+}
+
+func TestFromStructHidden(t *testing.T) {
+	type user struct {
+		ID       int64  `json:"id" pk:"true"`
+		Password string `json:"password" hidden:"true"`
+		Name     string `json:"name"`
+	}
+	var b Resource
+	assert.NoError(t, b.FromStruct(user{}))
+	assert.True(t, b.IsHidden("password"))
+	assert.False(t, b.IsSearchable("password"))
+	assert.False(t, b.IsHidden("name"))
+
+	type invalid struct {
+		Password string `json:"password" hidden:"true" sortable:"true"`
+	}
+	assert.EqualError(t, b.FromStruct(invalid{}), "field password is hidden, so it can not be sortable, full-text or aggregatable")
+}
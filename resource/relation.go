@@ -0,0 +1,151 @@
+package resource
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/stoewer/go-strcase"
+)
+
+// RelationKind is the kind of a relation between the rows of two resources
+type RelationKind string
+
+const (
+	// BelongsTo relates a row to the row of the other resource referenced by its foreign key
+	BelongsTo RelationKind = "belongs_to"
+	// HasMany relates a row to the rows of the other resource whose foreign key references it
+	HasMany RelationKind = "has_many"
+	// ManyToMany relates the rows of two resources through the rows of a join resource,
+	// which reference a row of each
+	ManyToMany RelationKind = "many_to_many"
+)
+
+// Valid returns true if the kind is one of the known kinds
+func (k RelationKind) Valid() bool {
+	switch k {
+	case BelongsTo, HasMany, ManyToMany:
+		return true
+	}
+	return false
+}
+
+// Relation relates the rows of a resource to the rows of another one
+type Relation struct {
+	Kind RelationKind `json:"kind"`
+	// Resource is the name of the related resource
+	Resource string `json:"resource"`
+	// ForeignKey is the field that references the other row: of the resource itself for
+	// belongs to relations, of the related resource for has many relations, and of the
+	// join resource, referencing the resource itself, for many to many relations
+	ForeignKey string `json:"foreign_key"`
+	// Through is the name of the join resource of many to many relations
+	Through string `json:"through"`
+	// OtherKey is the field of the join resource that references the related resource,
+	// for many to many relations
+	OtherKey string `json:"other_key"`
+}
+
+// relationOf returns the relation of a struct field tagged with a relation kind, as read by FromStruct
+func relationOf(tag reflect.StructTag) (Relation, bool) {
+	for _, kind := range []RelationKind{BelongsTo, HasMany, ManyToMany} {
+		if resource, ok := tag.Lookup(string(kind)); ok {
+			return Relation{
+				Kind:       kind,
+				Resource:   resource,
+				ForeignKey: tag.Get("foreign_key"),
+				Through:    tag.Get("through"),
+				OtherKey:   tag.Get("other_key"),
+			}, true
+		}
+	}
+	return Relation{}, false
+}
+
+// RelationNames returns the names of the relations of the resource, in alphabetical order
+func (b *Resource) RelationNames() []string {
+	names := make([]string, 0, len(b.Relations))
+	for name := range b.Relations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// routeSegments are the segments of the generated routes that follow the id of a row,
+// such as /users/{id}/restore, which the nested routes of the relations would collide with
+var routeSegments = []string{"restore", "purge"}
+
+// validateRelations returns an error if a relation has an invalid kind or misses the keys of
+// its kind, if the foreign key of a belongs to relation is not a field of the resource, or if
+// the segment of its nested routes is one of the other generated routes
+func (b *Resource) validateRelations() error {
+	for _, name := range b.RelationNames() {
+		rel := b.Relations[name]
+		switch {
+		case isRouteSegment(strcase.KebabCase(name)):
+			return fmt.Errorf("relation %s has the name of a route: %s", name, strcase.KebabCase(name))
+		case !rel.Kind.Valid():
+			return fmt.Errorf("relation %s has invalid kind: %s", name, rel.Kind)
+		case rel.Resource == "":
+			return fmt.Errorf("relation %s has no resource", name)
+		case rel.ForeignKey == "":
+			return fmt.Errorf("relation %s has no foreign key", name)
+		case rel.Kind == ManyToMany && (rel.Through == "" || rel.OtherKey == ""):
+			return fmt.Errorf("relation %s is many to many, which needs a join resource and its other key", name)
+		case rel.Kind == BelongsTo && !b.HasField(rel.ForeignKey):
+			return fmt.Errorf("relation %s has foreign key %s, which is not a field", name, rel.ForeignKey)
+		}
+	}
+	return nil
+}
+
+// isRouteSegment returns true if the segment is one of routeSegments
+func isRouteSegment(segment string) bool {
+	for _, s := range routeSegments {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
+
+// RelatedResources returns the related resource of the relation with the given name and, for
+// many to many relations, its join resource, found by name in the resources. It returns an
+// error if they are not found, or if the keys of the relation are not fields of them.
+func (b *Resource) RelatedResources(name string, resources []*Resource) (*Resource, *Resource, error) {
+	rel, ok := b.Relations[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s has no relation %s", b.Name, name)
+	}
+	find := func(name string) *Resource {
+		for _, r := range resources {
+			if r.Name == name {
+				return r
+			}
+		}
+		return nil
+	}
+	related := find(rel.Resource)
+	if related == nil {
+		return nil, nil, fmt.Errorf("relation %s of %s: resource %s not found", name, b.Name, rel.Resource)
+	}
+	switch rel.Kind {
+	case HasMany:
+		if !related.HasField(rel.ForeignKey) {
+			return nil, nil, fmt.Errorf("relation %s of %s: %s has no field %s", name, b.Name, related.Name, rel.ForeignKey)
+		}
+	case ManyToMany:
+		through := find(rel.Through)
+		if through == nil {
+			return nil, nil, fmt.Errorf("relation %s of %s: resource %s not found", name, b.Name, rel.Through)
+		}
+		for _, key := range []string{rel.ForeignKey, rel.OtherKey} {
+			if !through.HasField(key) {
+				return nil, nil, fmt.Errorf("relation %s of %s: %s has no field %s", name, b.Name, through.Name, key)
+			}
+		}
+		return related, through, nil
+	}
+	return related, nil, nil
+}
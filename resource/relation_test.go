@@ -0,0 +1,113 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromStructRelations(t *testing.T) {
+	type vehicle struct {
+		ID int64 `json:"id" pk:"true"`
+	}
+	type rentEvent struct {
+		ID     int64     `json:"id" pk:"true"`
+		UserID int64     `json:"user_id"`
+		User   *struct{} `json:"user" belongs_to:"user" foreign_key:"user_id"`
+	}
+	type user struct {
+		ID         int64       `json:"id" pk:"true"`
+		RentEvents []rentEvent `json:"rent_events" has_many:"rent_event" foreign_key:"user_id"`
+		Vehicles   []vehicle   `json:"vehicles" many_to_many:"vehicle" through:"user_vehicle" foreign_key:"user_id" other_key:"vehicle_id"`
+	}
+	var b Resource
+	require.NoError(t, b.FromStruct(user{}))
+	// relations are not fields
+	assert.Equal(t, []string{"id"}, keys(b.Fields))
+	assert.Equal(t, []string{"rent_events", "vehicles"}, b.RelationNames())
+	assert.Equal(t, Relation{Kind: HasMany, Resource: "rent_event", ForeignKey: "user_id"}, b.Relations["rent_events"])
+	assert.Equal(t, Relation{Kind: ManyToMany, Resource: "vehicle", ForeignKey: "user_id", Through: "user_vehicle", OtherKey: "vehicle_id"}, b.Relations["vehicles"])
+
+	var events Resource
+	require.NoError(t, events.FromStruct(rentEvent{}))
+	assert.Equal(t, Relation{Kind: BelongsTo, Resource: "user", ForeignKey: "user_id"}, events.Relations["user"])
+
+	type invalid struct {
+		ID   int64     `json:"id" pk:"true"`
+		User *struct{} `json:"user" belongs_to:"user" foreign_key:"user_id"`
+	}
+	assert.EqualError(t, b.FromStruct(invalid{}), "relation user has foreign key user_id, which is not a field")
+	type withoutThrough struct {
+		ID       int64     `json:"id" pk:"true"`
+		Vehicles []vehicle `json:"vehicles" many_to_many:"vehicle" foreign_key:"user_id"`
+	}
+	assert.EqualError(t, b.FromStruct(withoutThrough{}), "relation vehicles is many to many, which needs a join resource and its other key")
+}
+
+func TestFromJSONRelations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"name": "users",
+		"primary_key": "id",
+		"fields": {"id": {"type": "int"}},
+		"relations": {"rent_events": {"kind": "has_many", "resource": "rent_events", "foreign_key": "user_id"}}
+	}`), 0o600))
+	var b Resource
+	require.NoError(t, b.FromJSON(path))
+	assert.Equal(t, Relation{Kind: HasMany, Resource: "rent_events", ForeignKey: "user_id"}, b.Relations["rent_events"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "users", "relations": {"cars": {"kind": "has_one", "resource": "cars", "foreign_key": "user_id"}}}`), 0o600))
+	assert.EqualError(t, b.FromJSON(path), "relation cars has invalid kind: has_one")
+
+	// the nested routes of the relations can not collide with the other routes of a row
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "users", "relations": {"Purge": {"kind": "has_many", "resource": "purges", "foreign_key": "user_id"}}}`), 0o600))
+	assert.EqualError(t, b.FromJSON(path), "relation Purge has the name of a route: purge")
+}
+
+func TestRelatedResources(t *testing.T) {
+	users := Resource{
+		Name:   "users",
+		Fields: map[string]Field{"id": {}},
+		Relations: map[string]Relation{
+			"rent_events": {Kind: HasMany, Resource: "rent_events", ForeignKey: "user_id"},
+			"vehicles":    {Kind: ManyToMany, Resource: "vehicles", ForeignKey: "user_id", Through: "user_vehicles", OtherKey: "vehicle_id"},
+			"cars":        {Kind: HasMany, Resource: "cars", ForeignKey: "user_id"},
+			"notes":       {Kind: HasMany, Resource: "vehicles", ForeignKey: "user_id"},
+		},
+	}
+	events := Resource{Name: "rent_events", Fields: map[string]Field{"id": {}, "user_id": {}}}
+	vehicles := Resource{Name: "vehicles", Fields: map[string]Field{"id": {}}}
+	userVehicles := Resource{Name: "user_vehicles", Fields: map[string]Field{"id": {}, "user_id": {}, "vehicle_id": {}}}
+	resources := []*Resource{&users, &events, &vehicles, &userVehicles}
+
+	related, through, err := users.RelatedResources("rent_events", resources)
+	require.NoError(t, err)
+	assert.Equal(t, &events, related)
+	assert.Nil(t, through)
+
+	related, through, err = users.RelatedResources("vehicles", resources)
+	require.NoError(t, err)
+	assert.Equal(t, &vehicles, related)
+	assert.Equal(t, &userVehicles, through)
+
+	_, _, err = users.RelatedResources("cars", resources)
+	assert.EqualError(t, err, "relation cars of users: resource cars not found")
+	_, _, err = users.RelatedResources("notes", resources)
+	assert.EqualError(t, err, "relation notes of users: vehicles has no field user_id")
+	_, _, err = users.RelatedResources("missing", resources)
+	assert.EqualError(t, err, "users has no relation missing")
+}
+
+// keys returns the keys of the fields, in alphabetical order
+func keys(fields map[string]Field) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
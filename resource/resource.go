@@ -1,11 +1,14 @@
 package resource
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/franciscoescher/gosimplerest/validator"
 	"github.com/gofrs/uuid"
@@ -39,6 +42,21 @@ type Resource struct {
 	// UpdatedAtField is the name of the field that is used as update timestamp
 	// if null, no update timestamp is generated
 	UpdatedAtField null.String `json:"updated_at_field"`
+	// AllowIncludeDeleted allows the retrieve and search routes to return
+	// the soft deleted rows, with the include_deleted=true query param
+	AllowIncludeDeleted bool `json:"allow_include_deleted"`
+	// DefaultPageSize is the number of rows returned by the search route when no limit is given,
+	// if 0, all rows are returned, unless MaxPageSize is set
+	DefaultPageSize int `json:"default_page_size"`
+	// MaxPageSize is the maximum number of rows returned by the search route,
+	// larger limits are reduced to it, if 0, the limit is not bounded
+	MaxPageSize int `json:"max_page_size"`
+	// Relations relate the rows of the resource to the rows of other resources, by the name
+	// of the relation, which is the path of their nested routes
+	Relations map[string]Relation `json:"relations"`
+	// QueryTimeout is the maximum duration of each repository operation of the resource
+	// (in nanoseconds when read from JSON), if 0, the operations only end with the request
+	QueryTimeout time.Duration `json:"query_timeout"`
 	// Ommmit<Route Type>Route are flags that omit the generation of the specific route from the router
 	OmitCreateRoute        bool `json:"omit_create_route"`
 	OmitRetrieveRoute      bool `json:"omit_retrieve_route"`
@@ -47,11 +65,20 @@ type Resource struct {
 	OmitDeleteRoute        bool `json:"omit_delete_route"`
 	OmitSearchRoute        bool `json:"omit_search_route"`
 	OmitHeadRoutes         bool `json:"omit_head_routes"`
+	// The restore and purge routes are only added for resources with a SoftDeleteField
+	OmitRestoreRoute bool `json:"omit_restore_route"`
+	OmitPurgeRoute   bool `json:"omit_purge_route"`
+	// The aggregate route is only added for resources with aggregatable fields
+	OmitAggregateRoute bool `json:"omit_aggregate_route"`
+	// OmitRelationRoutes omits the nested routes of the relations of the resource
+	OmitRelationRoutes bool `json:"omit_relation_routes"`
 }
 
 type GeneratePrimaryKeyFunc func() any
 
 type Field struct {
+	// Type is the type of the values of the field, if empty, values are used as they come
+	Type Type `json:"type"`
 	// Validator is the validation rules for the field
 	Validator string `json:"validator"`
 	// Unsearchable is a flag that indicates that a field can not be used
@@ -59,6 +86,23 @@ type Field struct {
 	Unsearchable bool `json:"unsearchable"`
 	// Immutable is a flag that indicates that a field can not be updated
 	Immutable bool `json:"immutable"`
+	// Index is a flag that indicates that the column of the field is indexed
+	// in the tables created by the migrations
+	Index bool `json:"index"`
+	// Sortable is a flag that indicates that the search route can be sorted by the field
+	Sortable bool `json:"sortable"`
+	// Operators are the operators of the search filters of the field, besides
+	// equality, which is allowed for every searchable field
+	Operators []Operator `json:"operators"`
+	// FullText is a flag that indicates that the q param of the search route
+	// matches the words of the field, which must be a string
+	FullText bool `json:"full_text"`
+	// Aggregatable is a flag that indicates that the aggregate route can
+	// group the rows by the field and aggregate its values
+	Aggregatable bool `json:"aggregatable"`
+	// Hidden is a flag that indicates that the field is written but never returned or searched
+	// by the routes, including the rows of the resource embedded in others, such as a password hash
+	Hidden bool `json:"hidden"`
 }
 
 // FromJSON reads a JSON file and populates the model
@@ -71,7 +115,24 @@ func (b *Resource) FromJSON(filename string) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	for name, field := range b.Fields {
+		if !field.Type.Valid() {
+			return fmt.Errorf("field %s has invalid type: %s", name, field.Type)
+		}
+		err = validateOperators(name, field)
+		if err != nil {
+			return err
+		}
+		err = validateFullText(name, field)
+		if err != nil {
+			return err
+		}
+		err = validateHidden(name, field)
+		if err != nil {
+			return err
+		}
+	}
+	return b.validateRelations()
 }
 
 // Table returns the table name
@@ -96,7 +157,17 @@ The struct should have the following tags:
   - updated_at: used to get the updated at field
   - validate: used to get the validation rules
   - unsearchable: used to get the unsearchable fields
+  - index: used to get the indexed fields
+  - sortable: used to get the sortable fields
+  - operators: used to get the operators of the search filters, separated by commas
+  - full_text: used to get the fields matched by the full-text search
+  - aggregatable: used to get the fields of the aggregate route
+  - hidden: used to get the fields that are never returned by the routes
+  - belongs_to, has_many, many_to_many: used to get the relations, named as the field, which
+    is not a column, with the name of the related resource, and the keys of the relation in
+    the foreign_key, through and other_key tags
   - pk: used to get the primary key
+  - type: used to get the type of the field, if not present, it is inferred from the go type
 
 The omit route flags, OverwriteTableName and GeneratePrimaryKeyFunc are not populated by this function
 */
@@ -108,6 +179,7 @@ func (b *Resource) FromStruct(s any) error {
 	}
 	b.Name = strcase.SnakeCase(t.Name())
 	b.OverwriteTableName = null.NewString("", false)
+	b.Relations = nil
 
 	// Fields
 	// iterate over fields
@@ -131,11 +203,49 @@ func (b *Resource) FromStruct(s any) error {
 			val, ok := field.Tag.Lookup(tag)
 			return ok && (val == "" || val == "true")
 		}
+		// fields tagged with a relation kind hold the related rows, instead of a column
+		if rel, ok := relationOf(field.Tag); ok {
+			if b.Relations == nil {
+				b.Relations = make(map[string]Relation)
+			}
+			b.Relations[name] = rel
+			continue
+		}
+		fieldType := Type(field.Tag.Get("type"))
+		if fieldType == TypeAny {
+			fieldType = typeOf(field.Type)
+		}
+		if !fieldType.Valid() {
+			return fmt.Errorf("field %s has invalid type: %s", name, fieldType)
+		}
 		// get the field struct
 		fields[name] = Field{
+			Type:         fieldType,
 			Validator:    field.Tag.Get("validate"),
 			Immutable:    presentOrTrue("immutable"),
 			Unsearchable: presentOrTrue("unsearchable"),
+			Index:        presentOrTrue("index"),
+			Sortable:     presentOrTrue("sortable"),
+			FullText:     presentOrTrue("full_text"),
+			Aggregatable: presentOrTrue("aggregatable"),
+			Hidden:       presentOrTrue("hidden"),
+		}
+		if ops := field.Tag.Get("operators"); ops != "" {
+			f := fields[name]
+			for _, op := range strings.Split(ops, ",") {
+				f.Operators = append(f.Operators, Operator(strings.TrimSpace(op)))
+			}
+			fields[name] = f
+		}
+		err := validateOperators(name, fields[name])
+		if err == nil {
+			err = validateFullText(name, fields[name])
+		}
+		if err == nil {
+			err = validateHidden(name, fields[name])
+		}
+		if err != nil {
+			return err
 		}
 		// get the primary key
 		if presentOrTrue("pk") {
@@ -159,7 +269,7 @@ func (b *Resource) FromStruct(s any) error {
 	}
 	b.Fields = fields
 
-	return nil
+	return b.validateRelations()
 }
 
 // HasField returns true if the model has the given field
@@ -168,13 +278,71 @@ func (b *Resource) HasField(field string) bool {
 	return ok
 }
 
+// IsHidden returns true if the field is never returned by the routes
+func (b *Resource) IsHidden(field string) bool {
+	return b.Fields[field].Hidden
+}
+
 // HasField returns true if the model has the given field
 func (b *Resource) IsSearchable(field string) bool {
 	val, ok := b.Fields[field]
 	if !ok {
 		return false
 	}
-	return !val.Unsearchable
+	return !val.Unsearchable && !val.Hidden
+}
+
+// AllowsOperator returns true if the search filters of the field can use the operator.
+// Equality is allowed for every searchable field.
+func (b *Resource) AllowsOperator(field string, op Operator) bool {
+	if !b.IsSearchable(field) {
+		return false
+	}
+	if op == OpEq {
+		return true
+	}
+	for _, allowed := range b.Fields[field].Operators {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// FullTextFields returns the names of the fields matched by the full-text search, in alphabetical order
+func (b *Resource) FullTextFields() []string {
+	fields := make([]string, 0)
+	for _, field := range b.GetFieldNames() {
+		if b.Fields[field].FullText {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// IsAggregatable returns true if the aggregate route can group by the field and aggregate its values
+func (b *Resource) IsAggregatable(field string) bool {
+	return b.Fields[field].Aggregatable
+}
+
+// HasAggregatableFields returns true if the resource has a field marked as aggregatable
+func (b *Resource) HasAggregatableFields() bool {
+	for _, f := range b.Fields {
+		if f.Aggregatable {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSortable returns true if the rows can be sorted by the given field.
+// The primary key is always sortable, since it is the default order.
+func (b *Resource) IsSortable(field string) bool {
+	val, ok := b.Fields[field]
+	if !ok {
+		return false
+	}
+	return val.Sortable || field == b.PrimaryKey
 }
 
 // ValidateAllFields validates all fields of the model against the given data
@@ -214,6 +382,53 @@ func (b *Resource) ValidateField(v validator.Validator, field string, value any)
 	return nil
 }
 
+// ParseValue converts an input value of the given field, from a json body
+// or from a path or query param, to the type of the field
+func (b *Resource) ParseValue(field string, value any) (any, error) {
+	v, err := b.Fields[field].Type.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("field %s is invalid for type %s", field, b.Fields[field].Type)
+	}
+	return v, nil
+}
+
+// ParseRow converts the input values of the fields of the model in data to their types.
+// Keys that are not fields of the model are kept as they are.
+func (b *Resource) ParseRow(data map[string]any) error {
+	for k, v := range data {
+		if !b.HasField(k) {
+			continue
+		}
+		parsed, err := b.ParseValue(k, v)
+		if err != nil {
+			return err
+		}
+		data[k] = parsed
+	}
+	return nil
+}
+
+// ScanRow converts the values of a row read from a repository to the types of the fields
+func (b *Resource) ScanRow(row map[string]any) (map[string]any, error) {
+	for k, v := range row {
+		scanned, err := b.Fields[k].Type.Scan(v)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", k, err)
+		}
+		row[k] = scanned
+	}
+	return row, nil
+}
+
+// QueryContext returns the context for the repository operations of the resource,
+// derived from the given one with the QueryTimeout applied, if set
+func (b *Resource) QueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.QueryTimeout > 0 {
+		return context.WithTimeout(ctx, b.QueryTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // GeneratePrimaryKey generates a new primary key
 func (b *Resource) GeneratePrimaryKey() any {
 	if b.GeneratePrimaryKeyFunc != nil {
@@ -0,0 +1,362 @@
+package resource
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Type is the type of the values of a field. It drives how values are parsed from
+// request bodies, path params and query params, and how values read from the
+// repositories are converted, so the output is the same for every repository.
+type Type string
+
+const (
+	// TypeAny is the type of fields without a type, whose values are used as they come
+	TypeAny Type = ""
+	// TypeString values are strings
+	TypeString Type = "string"
+	// TypeInt values are int64
+	TypeInt Type = "int"
+	// TypeFloat values are float64
+	TypeFloat Type = "float"
+	// TypeBool values are bool, parsed from true, false, 1 and 0
+	TypeBool Type = "bool"
+	// TypeTime values are time.Time, parsed from RFC 3339 strings
+	TypeTime Type = "time"
+	// TypeDate values are strings in the 2006-01-02 format, parsed from such strings or from times
+	TypeDate Type = "date"
+	// TypeDecimal values are json.Number, so no precision is lost
+	TypeDecimal Type = "decimal"
+	// TypeJSON values are json.RawMessage, stored as the encoded json. Inputs are encoded
+	// as they are, so a string, even if it holds valid json, is a json string.
+	TypeJSON Type = "json"
+	// TypeUUID values are strings with a valid, lower case uuid
+	TypeUUID Type = "uuid"
+)
+
+// DateLayout is the format of the values of TypeDate fields
+const DateLayout = "2006-01-02"
+
+// timeLayouts are the formats accepted when a time is read from a string,
+// the first ones are used by the apis and the others by the databases
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	// the layout of time.Time.String, in which the sqlite driver stores times
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	DateLayout,
+}
+
+// Valid returns true if the type is one of the known types
+func (t Type) Valid() bool {
+	switch t {
+	case TypeAny, TypeString, TypeInt, TypeFloat, TypeBool, TypeTime, TypeDate, TypeDecimal, TypeJSON, TypeUUID:
+		return true
+	}
+	return false
+}
+
+// Parse converts an input value, coming from a json body decoded with UseNumber
+// or from the string of a path or query param, to the type.
+// nil is kept as nil, so nullable fields can be cleared.
+func (t Type) Parse(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch t {
+	case TypeString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case TypeInt:
+		return toInt(v)
+	case TypeFloat:
+		return toFloat(v)
+	case TypeBool:
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			return strconv.ParseBool(b)
+		}
+	case TypeTime:
+		return toTime(v)
+	case TypeDate:
+		// the same strings as Scan, so the values of the queries compare as the ones of the rows
+		if s, ok := v.(string); ok {
+			tm, err := time.Parse(DateLayout, s)
+			if err != nil {
+				return nil, err
+			}
+			return tm.Format(DateLayout), nil
+		}
+		if tm, ok := v.(time.Time); ok {
+			return tm.Format(DateLayout), nil
+		}
+	case TypeDecimal:
+		return toDecimal(v)
+	case TypeJSON:
+		return toJSON(v)
+	case TypeUUID:
+		if s, ok := v.(string); ok {
+			id, err := uuid.FromString(s)
+			if err != nil {
+				return nil, err
+			}
+			return id.String(), nil
+		}
+	default:
+		return normalize(v), nil
+	}
+	return nil, fmt.Errorf("invalid %s value %v", t, v)
+}
+
+// Scan converts a value read from a repository to the type.
+// Unlike Parse, it accepts the types returned by the database drivers,
+// such as []byte for strings and int64 for booleans.
+func (t Type) Scan(v any) (any, error) {
+	if b, ok := v.([]byte); ok && t != TypeJSON {
+		v = string(b)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t {
+	case TypeString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	case TypeInt:
+		return toInt(v)
+	case TypeFloat:
+		return toFloat(v)
+	case TypeBool:
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			return strconv.ParseBool(b)
+		}
+		n, err := toInt(v)
+		if err != nil {
+			return nil, err
+		}
+		return n != 0, nil
+	case TypeTime:
+		return toTime(v)
+	case TypeDate:
+		tm, err := toTime(v)
+		if err != nil {
+			return nil, err
+		}
+		return tm.Format(DateLayout), nil
+	case TypeDecimal:
+		return toDecimal(v)
+	case TypeJSON:
+		switch j := v.(type) {
+		case []byte:
+			return json.RawMessage(j), nil
+		case string:
+			return json.RawMessage(j), nil
+		}
+		return toJSON(v)
+	case TypeUUID:
+		if s, ok := v.(string); ok {
+			return strings.ToLower(s), nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	return normalize(v), nil
+}
+
+// normalize converts the json numbers and the integers of any size to
+// int64 or float64, keeping other values as they are
+func normalize(v any) any {
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+		return n.String()
+	case []byte:
+		return string(n)
+	case int, int8, int16, int32, uint, uint8, uint16, uint32, uint64:
+		i, _ := toInt(n)
+		return i
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+func toInt(v any) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case json.Number:
+		return n.Int64()
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("invalid int value %v", v)
+		}
+		// -2^63 converts exactly, but 2^63, the float64 closest to math.MaxInt64, overflows
+		if n < math.MinInt64 || n >= -math.MinInt64 {
+			return 0, fmt.Errorf("int value %v out of range", v)
+		}
+		return int64(n), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("int value %v out of range", v)
+		}
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("invalid int value %v", v)
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	i, err := toInt(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid float value %v", v)
+	}
+	return float64(i), nil
+}
+
+func toTime(v any) (time.Time, error) {
+	switch tm := v.(type) {
+	case time.Time:
+		return tm, nil
+	case string:
+		for _, layout := range timeLayouts {
+			parsed, err := time.Parse(layout, tm)
+			if err == nil {
+				return parsed, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time value %v", v)
+}
+
+func toDecimal(v any) (json.Number, error) {
+	var s string
+	switch n := v.(type) {
+	case json.Number:
+		s = n.String()
+	case string:
+		s = n
+	case float64:
+		s = strconv.FormatFloat(n, 'f', -1, 64)
+	default:
+		i, err := toInt(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid decimal value %v", v)
+		}
+		s = strconv.FormatInt(i, 10)
+	}
+	if _, ok := new(big.Rat).SetString(s); !ok {
+		return "", fmt.Errorf("invalid decimal value %v", v)
+	}
+	return json.Number(s), nil
+}
+
+func toJSON(v any) (json.RawMessage, error) {
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	nullStringType = reflect.TypeOf(null.String{})
+	nullIntType    = reflect.TypeOf(null.Int{})
+	nullFloatType  = reflect.TypeOf(null.Float{})
+	nullBoolType   = reflect.TypeOf(null.Bool{})
+	nullTimeType   = reflect.TypeOf(null.Time{})
+
+	sqlNullStringType  = reflect.TypeOf(sql.NullString{})
+	sqlNullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	sqlNullInt32Type   = reflect.TypeOf(sql.NullInt32{})
+	sqlNullInt16Type   = reflect.TypeOf(sql.NullInt16{})
+	sqlNullByteType    = reflect.TypeOf(sql.NullByte{})
+	sqlNullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	sqlNullBoolType    = reflect.TypeOf(sql.NullBool{})
+	sqlNullTimeType    = reflect.TypeOf(sql.NullTime{})
+)
+
+// typeOf returns the type of the field for a go type
+func typeOf(t reflect.Type) Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// the null types of guregu/null and database/sql are structs, typed by the value they hold
+	switch t {
+	case timeType, nullTimeType, sqlNullTimeType:
+		return TypeTime
+	case rawMessageType:
+		return TypeJSON
+	case uuidType:
+		return TypeUUID
+	case nullStringType, sqlNullStringType:
+		return TypeString
+	case nullIntType, sqlNullInt64Type, sqlNullInt32Type, sqlNullInt16Type, sqlNullByteType:
+		return TypeInt
+	case nullFloatType, sqlNullFloat64Type:
+		return TypeFloat
+	case nullBoolType, sqlNullBoolType:
+		return TypeBool
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return TypeString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeInt
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat
+	case reflect.Bool:
+		return TypeBool
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return TypeJSON
+	}
+	return TypeAny
+}
@@ -0,0 +1,180 @@
+package resource
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	null "gopkg.in/guregu/null.v3"
+)
+
+func TestTypeParse(t *testing.T) {
+	tm := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	tests := []struct {
+		name  string
+		t     Type
+		input any
+		want  any
+	}{
+		{"any number", TypeAny, json.Number("9007199254740993"), int64(9007199254740993)},
+		{"any float", TypeAny, json.Number("1.5"), 1.5},
+		{"any string", TypeAny, "a", "a"},
+		{"nil", TypeInt, nil, nil},
+		{"string", TypeString, "a", "a"},
+		{"int number", TypeInt, json.Number("9007199254740993"), int64(9007199254740993)},
+		{"int param", TypeInt, "42", int64(42)},
+		{"float number", TypeFloat, json.Number("1.25"), 1.25},
+		{"float param", TypeFloat, "3", float64(3)},
+		{"bool", TypeBool, true, true},
+		{"bool param", TypeBool, "1", true},
+		{"time", TypeTime, "2023-04-05T06:07:08Z", tm},
+		{"date", TypeDate, "2023-04-05", "2023-04-05"},
+		{"date time", TypeDate, tm, "2023-04-05"},
+		{"decimal", TypeDecimal, json.Number("10.10"), json.Number("10.10")},
+		{"decimal param", TypeDecimal, "0.30", json.Number("0.30")},
+		{"json object", TypeJSON, map[string]any{"a": json.Number("1")}, json.RawMessage(`{"a":1}`)},
+		{"json string", TypeJSON, `[1,2]`, json.RawMessage(`"[1,2]"`)},
+		{"json numeric string", TypeJSON, "123", json.RawMessage(`"123"`)},
+		{"json number", TypeJSON, json.Number("123"), json.RawMessage(`123`)},
+		{"uuid", TypeUUID, "6BA7B810-9DAD-11D1-80B4-00C04FD430C8", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.t.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTypeParseInvalid(t *testing.T) {
+	tests := []struct {
+		t     Type
+		input any
+	}{
+		{TypeString, json.Number("1")},
+		{TypeInt, "a"},
+		{TypeInt, json.Number("1.5")},
+		{TypeInt, 1.5},
+		{TypeInt, 1e20},
+		{TypeInt, math.Inf(-1)},
+		{TypeFloat, "a"},
+		{TypeBool, "yes"},
+		{TypeTime, "yesterday"},
+		{TypeDate, "2023-04-05T06:07:08Z"},
+		{TypeDecimal, "1,5"},
+		{TypeUUID, "not-a-uuid"},
+	}
+	for _, tt := range tests {
+		_, err := tt.t.Parse(tt.input)
+		assert.Error(t, err, "%s %v", tt.t, tt.input)
+	}
+}
+
+func TestTypeScan(t *testing.T) {
+	tm := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	tests := []struct {
+		name  string
+		t     Type
+		input any
+		want  any
+	}{
+		{"any bytes", TypeAny, []byte("a"), "a"},
+		{"any int", TypeAny, int32(1), int64(1)},
+		{"string bytes", TypeString, []byte("a"), "a"},
+		{"int bytes", TypeInt, []byte("9007199254740993"), int64(9007199254740993)},
+		{"int float", TypeInt, float64(3), int64(3)},
+		{"float bytes", TypeFloat, []byte("1.5"), 1.5},
+		{"bool tinyint", TypeBool, int64(1), true},
+		{"bool bytes", TypeBool, []byte("0"), false},
+		{"time", TypeTime, tm, tm},
+		{"time bytes", TypeTime, []byte("2023-04-05 06:07:08"), tm},
+		{"time json", TypeTime, "2023-04-05T06:07:08Z", tm},
+		{"date", TypeDate, tm, "2023-04-05"},
+		{"date sqlite", TypeDate, "2023-04-05 00:00:00 +0000 UTC", "2023-04-05"},
+		{"decimal bytes", TypeDecimal, []byte("10.10"), json.Number("10.10")},
+		{"json bytes", TypeJSON, []byte(`{"a":1}`), json.RawMessage(`{"a":1}`)},
+		{"json decoded", TypeJSON, []any{"a"}, json.RawMessage(`["a"]`)},
+		{"uuid", TypeUUID, []byte("6BA7B810-9DAD-11D1-80B4-00C04FD430C8"), "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.t.Scan(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFromStructTypes(t *testing.T) {
+	type Order struct {
+		ID       uuid.UUID       `db:"id" pk:"true"`
+		Number   int64           `db:"number"`
+		Paid     bool            `db:"paid"`
+		Total    string          `db:"total" type:"decimal"`
+		Rate     null.Float      `db:"rate"`
+		DueDate  time.Time       `db:"due_date" type:"date"`
+		PaidAt   null.Time       `db:"paid_at"`
+		Metadata json.RawMessage `db:"metadata"`
+		Notes    *string         `db:"notes"`
+	}
+	r := Resource{}
+	err := r.FromStruct(Order{})
+	assert.NoError(t, err)
+	assert.Equal(t, TypeUUID, r.Fields["id"].Type)
+	assert.Equal(t, TypeInt, r.Fields["number"].Type)
+	assert.Equal(t, TypeBool, r.Fields["paid"].Type)
+	assert.Equal(t, TypeDecimal, r.Fields["total"].Type)
+	assert.Equal(t, TypeFloat, r.Fields["rate"].Type)
+	assert.Equal(t, TypeDate, r.Fields["due_date"].Type)
+	assert.Equal(t, TypeTime, r.Fields["paid_at"].Type)
+	assert.Equal(t, TypeJSON, r.Fields["metadata"].Type)
+	assert.Equal(t, TypeString, r.Fields["notes"].Type)
+
+	type SQLNulls struct {
+		ID      sql.NullInt64   `db:"id" pk:"true"`
+		Name    sql.NullString  `db:"name"`
+		Year    sql.NullInt32   `db:"year"`
+		Rate    sql.NullFloat64 `db:"rate"`
+		Paid    sql.NullBool    `db:"paid"`
+		PaidAt  sql.NullTime    `db:"paid_at"`
+		Version *sql.NullInt16  `db:"version"`
+	}
+	err = r.FromStruct(SQLNulls{})
+	assert.NoError(t, err)
+	assert.Equal(t, TypeInt, r.Fields["id"].Type)
+	assert.Equal(t, TypeString, r.Fields["name"].Type)
+	assert.Equal(t, TypeInt, r.Fields["year"].Type)
+	assert.Equal(t, TypeFloat, r.Fields["rate"].Type)
+	assert.Equal(t, TypeBool, r.Fields["paid"].Type)
+	assert.Equal(t, TypeTime, r.Fields["paid_at"].Type)
+	assert.Equal(t, TypeInt, r.Fields["version"].Type)
+
+	type Invalid struct {
+		Name string `type:"text"`
+	}
+	err = r.FromStruct(Invalid{})
+	assert.Error(t, err)
+}
+
+func TestParseRow(t *testing.T) {
+	r := Resource{
+		PrimaryKey: "id",
+		Fields: map[string]Field{
+			"id":    {Type: TypeInt},
+			"total": {Type: TypeDecimal},
+			"name":  {},
+		},
+	}
+	data := map[string]any{"id": json.Number("1"), "total": json.Number("1.10"), "name": "a", "other": 1}
+	err := r.ParseRow(data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": int64(1), "total": json.Number("1.10"), "name": "a", "other": 1}, data)
+
+	err = r.ParseRow(map[string]any{"id": "a"})
+	assert.EqualError(t, err, "field id is invalid for type int")
+}
@@ -1,6 +1,8 @@
 package gosimplerest
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -8,6 +10,7 @@ import (
 	"github.com/franciscoescher/gosimplerest/logger"
 	"github.com/franciscoescher/gosimplerest/repository"
 	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/franciscoescher/gosimplerest/searchindex"
 	"github.com/franciscoescher/gosimplerest/validator"
 	"github.com/stoewer/go-strcase"
 )
@@ -41,6 +44,14 @@ type AddHandlersBaseParams struct {
 	Respository repository.RepositoryInterface
 	Validator   validator.Validator
 	Logger      logger.Logger
+	// SearchIndex matches the q param of the search routes of the resources with
+	// full-text fields, and is kept in sync by the routes that write rows.
+	// Rows written before it was set can be indexed with searchindex.Rebuild.
+	SearchIndex searchindex.SearchIndex
+	// SkipMismatchedResources checks the resources against the schema of the repository,
+	// if it implements repository.SchemaChecker, and does not add the routes of
+	// the resources that drifted from it, logging the drifts instead
+	SkipMismatchedResources bool
 }
 
 type AddHandlersParams struct {
@@ -57,12 +68,27 @@ func AddHandlers(params AddHandlersParams) {
 	if params.Logger == nil {
 		params.Logger = &logger.BlankLogger{}
 	}
+	report := checkSchema(params.AddHandlersBaseParams)
+	resources := make([]*resource.Resource, len(params.Resources))
 	for i := range params.Resources {
+		resources[i] = &params.Resources[i]
+	}
+	relations := resolveRelations(params, report, resources)
+	for i := range params.Resources {
+		if report.Mismatched(params.Resources[i].Name) {
+			continue
+		}
+		if shadowed := handlers.ShadowedFields(&params.Resources[i]); len(shadowed) > 0 {
+			params.Logger.Info(fmt.Sprintf("fields %s of %s are also query params, so they are only filtered in the field[eq]=value format",
+				strings.Join(shadowed, ", "), params.Resources[i].Name))
+		}
 		p := &handlers.GetHandlerFuncParams{
-			Logger:     params.Logger,
-			Validate:   params.Validator,
-			Resource:   &params.Resources[i],
-			Repository: params.Respository,
+			Logger:      params.Logger,
+			Validate:    params.Validator,
+			Resource:    &params.Resources[i],
+			Repository:  params.Respository,
+			SearchIndex: params.SearchIndex,
+			Includes:    includes(relations[params.Resources[i].Name]),
 		}
 		var sb strings.Builder
 		sb.WriteString("/")
@@ -71,6 +97,11 @@ func AddHandlers(params AddHandlersParams) {
 		nameID := params.AddParamFunc(name, "id")
 
 		h := params.AddRouteFunctions
+		// added before the retrieve route, so the routers that match the routes
+		// in the order they were added do not read _aggregate as an id
+		if params.Resources[i].HasAggregatableFields() && !params.Resources[i].OmitAggregateRoute {
+			h.Get(name+"/_aggregate", handlers.AggregateHandler(p))
+		}
 		if !params.Resources[i].OmitCreateRoute {
 			h.Post(name, handlers.CreateHandler(p))
 		}
@@ -93,5 +124,115 @@ func AddHandlers(params AddHandlersParams) {
 			h.Head(nameID, handlers.RetrieveHandler(p))
 			h.Head(name, handlers.SearchHandler(p))
 		}
+		if params.Resources[i].SoftDeleteField.Valid {
+			if !params.Resources[i].OmitRestoreRoute {
+				h.Post(nameID+"/restore", handlers.RestoreHandler(p))
+			}
+			if !params.Resources[i].OmitPurgeRoute {
+				h.Delete(nameID+"/purge", handlers.PurgeHandler(p))
+			}
+		}
+		if !params.Resources[i].OmitRelationRoutes {
+			addRelationHandlers(params, relations, p, nameID)
+		}
+	}
+}
+
+// resolveRelations returns the relations of the resources, by the names of the resources
+// and of the relations, with their related resources. Relations with resources that are
+// not found, or drifted from the schema, are logged and left out.
+func resolveRelations(params AddHandlersParams, report repository.SchemaReport, resources []*resource.Resource) map[string]map[string]handlers.Include {
+	relations := make(map[string]map[string]handlers.Include, len(resources))
+	for _, b := range resources {
+		if report.Mismatched(b.Name) {
+			continue
+		}
+		relations[b.Name] = make(map[string]handlers.Include, len(b.Relations))
+		for _, name := range b.RelationNames() {
+			related, through, err := b.RelatedResources(name, resources)
+			if err != nil {
+				params.Logger.Error(err)
+				continue
+			}
+			if report.Mismatched(related.Name) || through != nil && report.Mismatched(through.Name) {
+				continue
+			}
+			relations[b.Name][name] = handlers.Include{Relation: b.Relations[name], Resource: related, Through: through}
+		}
+	}
+	return relations
+}
+
+// includes returns the relations whose rows can be included in the rows of a resource, which
+// are the ones whose related rows are exposed by the related resource: by its retrieve route
+// for belongs to relations, and by its search route for the other ones
+func includes(relations map[string]handlers.Include) map[string]handlers.Include {
+	exposed := make(map[string]handlers.Include, len(relations))
+	for name, inc := range relations {
+		if inc.Relation.Kind == resource.BelongsTo && inc.Resource.OmitRetrieveRoute ||
+			inc.Relation.Kind != resource.BelongsTo && inc.Resource.OmitSearchRoute {
+			continue
+		}
+		exposed[name] = inc
+	}
+	return exposed
+}
+
+// addRelationHandlers adds the nested routes of the relations of the resource of p, under its
+// route with the id param: a retrieve route for belongs to relations, and a search and create
+// route for the other ones, unless the related resource omits them. The rows of the nested
+// routes can include the relations of the related resource.
+func addRelationHandlers(params AddHandlersParams, relations map[string]map[string]handlers.Include, p *handlers.GetHandlerFuncParams, nameID string) {
+	h := params.AddRouteFunctions
+	for _, name := range p.Resource.RelationNames() {
+		inc, ok := relations[p.Resource.Name][name]
+		if !ok {
+			continue
+		}
+		related := inc.Resource
+		rp := &handlers.RelationHandlerFuncParams{
+			GetHandlerFuncParams: *p,
+			Parent:               p.Resource,
+			Relation:             inc.Relation,
+			Through:              inc.Through,
+		}
+		rp.Resource = related
+		rp.Includes = includes(relations[related.Name])
+		path := nameID + "/" + strcase.KebabCase(name)
+		if rp.Relation.Kind == resource.BelongsTo {
+			if !related.OmitRetrieveRoute {
+				h.Get(path, handlers.RelationRetrieveHandler(rp))
+			}
+			continue
+		}
+		if !related.OmitSearchRoute {
+			h.Get(path, handlers.RelationSearchHandler(rp))
+		}
+		if !related.OmitCreateRoute {
+			h.Post(path, handlers.RelationCreateHandler(rp))
+		}
+	}
+}
+
+// checkSchema checks the schema of the repository if SkipMismatchedResources is set,
+// returning an empty report otherwise. If the check fails, the error is logged
+// and all resources are added.
+func checkSchema(params AddHandlersBaseParams) repository.SchemaReport {
+	checker, ok := params.Respository.(repository.SchemaChecker)
+	if !params.SkipMismatchedResources || !ok {
+		return repository.SchemaReport{}
+	}
+	resources := make([]*resource.Resource, len(params.Resources))
+	for i := range params.Resources {
+		resources[i] = &params.Resources[i]
+	}
+	report, err := checker.CheckSchema(context.Background(), resources...)
+	if err != nil {
+		params.Logger.Error(err)
+		return repository.SchemaReport{}
+	}
+	if !report.OK() {
+		params.Logger.Error("routes of resources with schema drift are not added:\n" + strings.TrimSpace(report.String()))
 	}
+	return report
 }
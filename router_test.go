@@ -0,0 +1,199 @@
+package gosimplerest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/handlers"
+	"github.com/franciscoescher/gosimplerest/repository/sqlite"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// recordRoutes returns route functions that record the routes added, as method and path
+func recordRoutes(routes *[]string) AddRouteFunctions {
+	add := func(method string) AddRouteFunc {
+		return func(name string, h http.HandlerFunc) {
+			*routes = append(*routes, method+" "+name)
+		}
+	}
+	return AddRouteFunctions{
+		Post:   add(http.MethodPost),
+		Get:    add(http.MethodGet),
+		Put:    add(http.MethodPut),
+		Patch:  add(http.MethodPatch),
+		Delete: add(http.MethodDelete),
+		Head:   add(http.MethodHead),
+	}
+}
+
+func TestAddHandlersSkipMismatchedResources(t *testing.T) {
+	db, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	users := resource.Resource{
+		Name:           "users",
+		PrimaryKey:     "uuid",
+		Fields:         map[string]resource.Field{"uuid": {}, "first_name": {}},
+		OmitHeadRoutes: true,
+	}
+	notes := resource.Resource{
+		Name:       "notes",
+		PrimaryKey: "id",
+		Fields:     map[string]resource.Field{"id": {}},
+	}
+	require.NoError(t, sqlite.AutoMigrate(db, &users))
+
+	routes := make([]string, 0)
+	AddHandlers(AddHandlersParams{
+		AddHandlersBaseParams: AddHandlersBaseParams{
+			Resources:               []resource.Resource{users, notes},
+			Respository:             sqlite.NewRepository(db),
+			SkipMismatchedResources: true,
+		},
+		AddRouteFunctions: recordRoutes(&routes),
+		AddParamFunc:      func(name string, param string) string { return name + "/{" + param + "}" },
+	})
+
+	// notes has no table, so its routes are not added
+	assert.Equal(t, []string{
+		"POST /users",
+		"GET /users/{id}",
+		"PUT /users",
+		"PATCH /users",
+		"DELETE /users/{id}",
+		"GET /users",
+	}, routes)
+}
+
+func TestAddHandlersSoftDeleteRoutes(t *testing.T) {
+	users := resource.Resource{
+		Name:            "users",
+		PrimaryKey:      "uuid",
+		Fields:          map[string]resource.Field{"uuid": {}, "deleted_at": {}},
+		SoftDeleteField: null.NewString("deleted_at", true),
+		OmitCreateRoute: true, OmitRetrieveRoute: true, OmitUpdateRoute: true, OmitPartialUpdateRoute: true,
+		OmitDeleteRoute: true, OmitSearchRoute: true, OmitHeadRoutes: true,
+	}
+	withoutPurge := users
+	withoutPurge.Name = "notes"
+	withoutPurge.OmitPurgeRoute = true
+	hard := users
+	hard.Name = "orders"
+	hard.SoftDeleteField = null.String{}
+
+	routes := make([]string, 0)
+	AddHandlers(AddHandlersParams{
+		AddHandlersBaseParams: AddHandlersBaseParams{Resources: []resource.Resource{users, withoutPurge, hard}},
+		AddRouteFunctions:     recordRoutes(&routes),
+		AddParamFunc:          func(name string, param string) string { return name + "/{" + param + "}" },
+	})
+
+	// the routes are only added to resources with a soft delete field
+	assert.Equal(t, []string{
+		"POST /users/{id}/restore",
+		"DELETE /users/{id}/purge",
+		"POST /notes/{id}/restore",
+	}, routes)
+}
+
+func TestAddHandlersAggregateRoute(t *testing.T) {
+	orders := resource.Resource{
+		Name:            "orders",
+		PrimaryKey:      "id",
+		Fields:          map[string]resource.Field{"id": {}, "total": {Type: resource.TypeDecimal, Aggregatable: true}},
+		OmitCreateRoute: true, OmitUpdateRoute: true, OmitPartialUpdateRoute: true,
+		OmitDeleteRoute: true, OmitSearchRoute: true, OmitHeadRoutes: true,
+	}
+	withoutAggregate := orders
+	withoutAggregate.Name = "invoices"
+	withoutAggregate.OmitAggregateRoute = true
+	notAggregatable := orders
+	notAggregatable.Name = "notes"
+	notAggregatable.Fields = map[string]resource.Field{"id": {}}
+
+	routes := make([]string, 0)
+	AddHandlers(AddHandlersParams{
+		AddHandlersBaseParams: AddHandlersBaseParams{Resources: []resource.Resource{orders, withoutAggregate, notAggregatable}},
+		AddRouteFunctions:     recordRoutes(&routes),
+		AddParamFunc:          func(name string, param string) string { return name + "/{" + param + "}" },
+	})
+
+	// the route is only added to resources with aggregatable fields, before the retrieve route
+	assert.Equal(t, []string{
+		"GET /orders/_aggregate",
+		"GET /orders/{id}",
+		"GET /invoices/{id}",
+		"GET /notes/{id}",
+	}, routes)
+}
+
+func TestAddHandlersRelationRoutes(t *testing.T) {
+	omitted := resource.Resource{
+		OmitCreateRoute: true, OmitRetrieveRoute: true, OmitUpdateRoute: true, OmitPartialUpdateRoute: true,
+		OmitDeleteRoute: true, OmitSearchRoute: true, OmitHeadRoutes: true,
+	}
+	users := omitted
+	users.Name, users.PrimaryKey = "users", "id"
+	users.Fields = map[string]resource.Field{"id": {}}
+	users.OmitRetrieveRoute = false
+	users.Relations = map[string]resource.Relation{
+		"rent_events": {Kind: resource.HasMany, Resource: "rent_events", ForeignKey: "user_id"},
+		"vehicles":    {Kind: resource.ManyToMany, Resource: "vehicles", ForeignKey: "user_id", Through: "user_vehicles", OtherKey: "vehicle_id"},
+		"cars":        {Kind: resource.HasMany, Resource: "cars", ForeignKey: "user_id"},
+	}
+	events := resource.Resource{
+		Name:            "rent_events",
+		PrimaryKey:      "id",
+		Fields:          map[string]resource.Field{"id": {}, "user_id": {}},
+		Relations:       map[string]resource.Relation{"user": {Kind: resource.BelongsTo, Resource: "users", ForeignKey: "user_id"}},
+		OmitUpdateRoute: true, OmitPartialUpdateRoute: true, OmitDeleteRoute: true, OmitHeadRoutes: true,
+	}
+	vehicles := omitted
+	vehicles.Name, vehicles.PrimaryKey = "vehicles", "id"
+	vehicles.Fields = map[string]resource.Field{"id": {}}
+	vehicles.OmitSearchRoute = false
+	userVehicles := omitted
+	userVehicles.Name, userVehicles.PrimaryKey = "user_vehicles", "id"
+	userVehicles.Fields = map[string]resource.Field{"id": {}, "user_id": {}, "vehicle_id": {}}
+
+	routes := make([]string, 0)
+	AddHandlers(AddHandlersParams{
+		AddHandlersBaseParams: AddHandlersBaseParams{Resources: []resource.Resource{users, events, vehicles, userVehicles}},
+		AddRouteFunctions:     recordRoutes(&routes),
+		AddParamFunc:          func(name string, param string) string { return name + "/{" + param + "}" },
+	})
+
+	// the nested routes follow the routes of the related resources,
+	// and the relations with resources that are not found are skipped
+	assert.Equal(t, []string{
+		"GET /users/{id}",
+		"GET /users/{id}/rent-events",
+		"POST /users/{id}/rent-events",
+		"GET /users/{id}/vehicles",
+		"POST /rent-events",
+		"GET /rent-events/{id}",
+		"GET /rent-events",
+		"GET /rent-events/{id}/user",
+		"GET /vehicles",
+	}, routes)
+}
+
+func TestIncludes(t *testing.T) {
+	users := &resource.Resource{Name: "users", OmitSearchRoute: true}
+	events := &resource.Resource{Name: "rent_events", OmitRetrieveRoute: true}
+	relations := map[string]handlers.Include{
+		"user":        {Relation: resource.Relation{Kind: resource.BelongsTo}, Resource: users},
+		"rent_events": {Relation: resource.Relation{Kind: resource.HasMany}, Resource: events},
+		"owners":      {Relation: resource.Relation{Kind: resource.ManyToMany}, Resource: users},
+	}
+
+	// the related rows must be exposed by the route of the related resource that reads them
+	included := includes(relations)
+	assert.Len(t, included, 2)
+	assert.Contains(t, included, "user")
+	assert.Contains(t, included, "rent_events")
+}
@@ -0,0 +1,230 @@
+// Package bleveindex is the default implementation of searchindex.SearchIndex,
+// on the embedded full-text engine bleve. Each resource has its own bleve index,
+// in memory or in a directory, with the full-text fields of its rows.
+package bleveindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/franciscoescher/gosimplerest/searchindex"
+)
+
+// fuzzyLength is the length from which the terms of a search match words
+// with one edit, such as a typo, so short terms do not match everything
+const fuzzyLength = 4
+
+// ErrMappingChanged is returned when the index of a resource in the directory was created
+// with other full-text fields. Its directory has to be removed, so the index is created
+// again, and filled with searchindex.Rebuild.
+var ErrMappingChanged = errors.New("the full-text fields of the index changed")
+
+// Index is a searchindex.SearchIndex on bleve
+type Index struct {
+	// dir is the directory of the indexes, if empty, they are kept in memory
+	dir     string
+	mu      sync.Mutex
+	indexes map[string]bleve.Index
+}
+
+// Compile-time check that Index implements the SearchIndex interface
+var _ searchindex.SearchIndex = (*Index)(nil)
+
+// New returns an Index that keeps the index of each resource in a subdirectory of dir,
+// named as its table, or in memory if dir is empty
+func New(dir string) *Index {
+	return &Index{dir: dir, indexes: make(map[string]bleve.Index)}
+}
+
+// Close closes the indexes of the resources, returning the first error
+func (x *Index) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var first error
+	for table, index := range x.indexes {
+		if err := index.Close(); err != nil && first == nil {
+			first = fmt.Errorf("%s: %w", table, err)
+		}
+		delete(x.indexes, table)
+	}
+	return first
+}
+
+// index returns the bleve index of the resource, opening or creating it on first use
+func (x *Index) index(b *resource.Resource) (bleve.Index, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if index, ok := x.indexes[b.Table()]; ok {
+		return index, nil
+	}
+	var index bleve.Index
+	var err error
+	if x.dir == "" {
+		index, err = bleve.NewMemOnly(indexMapping(b))
+	} else {
+		path := filepath.Join(x.dir, b.Table())
+		index, err = bleve.Open(path)
+		if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+			index, err = bleve.New(path, indexMapping(b))
+		} else if err == nil {
+			err = checkMapping(index, b)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Table(), err)
+	}
+	x.indexes[b.Table()] = index
+	return index, nil
+}
+
+// indexMapping maps the full-text fields of the resource to analyzed text,
+// stored with their term vectors so the matches can be highlighted
+func indexMapping(b *resource.Resource) mapping.IndexMapping {
+	doc := bleve.NewDocumentStaticMapping()
+	for _, field := range b.FullTextFields() {
+		text := bleve.NewTextFieldMapping()
+		text.Analyzer = standard.Name
+		doc.AddFieldMappingsAt(field, text)
+	}
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	return m
+}
+
+// checkMapping returns ErrMappingChanged, closing the index, if the index in the directory
+// was created with other full-text fields than the ones of the resource
+func checkMapping(index bleve.Index, b *resource.Resource) error {
+	var fields []string
+	if m, ok := index.Mapping().(*mapping.IndexMappingImpl); ok && m.DefaultMapping != nil {
+		for field := range m.DefaultMapping.Properties {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	want := b.FullTextFields()
+	sort.Strings(want)
+	if strings.Join(fields, ",") == strings.Join(want, ",") {
+		return nil
+	}
+	index.Close()
+	return fmt.Errorf("%w: indexed %v, full-text %v", ErrMappingChanged, fields, want)
+}
+
+// docID returns the id of the document of a row, from its primary key
+func docID(id any) string {
+	return fmt.Sprint(id)
+}
+
+func (x *Index) Index(ctx context.Context, b *resource.Resource, row map[string]any) error {
+	index, err := x.index(b)
+	if err != nil {
+		return err
+	}
+	id, ok := row[b.PrimaryKey]
+	if !ok || id == nil {
+		return fmt.Errorf("%s: row without primary key", b.Table())
+	}
+	doc := make(map[string]any)
+	for _, field := range b.FullTextFields() {
+		if v, ok := row[field].(string); ok {
+			doc[field] = v
+		}
+	}
+	return index.Index(docID(id), doc)
+}
+
+func (x *Index) Delete(ctx context.Context, b *resource.Resource, id any) error {
+	index, err := x.index(b)
+	if err != nil {
+		return err
+	}
+	return index.Delete(docID(id))
+}
+
+// Search matches rows with every term of the text, as split by repository.TextTerms,
+// starting a word of one of the full-text fields, or equal to one of them but for
+// one edit, for the terms of 4 or more characters. Rows with the same score are
+// ordered by their primary key, as text.
+func (x *Index) Search(ctx context.Context, b *resource.Resource, q searchindex.Query) (searchindex.Result, error) {
+	terms := repository.TextTerms(q.Text)
+	if len(terms) == 0 {
+		return searchindex.Result{}, repository.NewError(repository.ErrInvalidQuery, errors.New("search without terms"))
+	}
+	fields := b.FullTextFields()
+	if len(fields) == 0 {
+		return searchindex.Result{}, repository.NewError(repository.ErrInvalidQuery, errors.New("resource without full-text fields"))
+	}
+	index, err := x.index(b)
+	if err != nil {
+		return searchindex.Result{}, err
+	}
+
+	conjuncts := make([]query.Query, len(terms))
+	for i, term := range terms {
+		disjuncts := make([]query.Query, 0, len(fields)*2)
+		for _, field := range fields {
+			prefix := bleve.NewPrefixQuery(term)
+			prefix.SetField(field)
+			match := bleve.NewMatchQuery(term)
+			match.SetField(field)
+			if len([]rune(term)) >= fuzzyLength {
+				match.SetFuzziness(1)
+			}
+			disjuncts = append(disjuncts, prefix, match)
+		}
+		conjuncts[i] = bleve.NewDisjunctionQuery(disjuncts...)
+	}
+
+	size := q.Limit
+	if size <= 0 {
+		count, err := index.DocCount()
+		if err != nil {
+			return searchindex.Result{}, err
+		}
+		size = int(count)
+	}
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(conjuncts...), size, q.Offset, false)
+	req.SortBy([]string{"-_score", "_id"})
+	req.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	req.Highlight.Fields = fields
+	res, err := index.SearchInContext(ctx, req)
+	if err != nil {
+		return searchindex.Result{}, err
+	}
+
+	result := searchindex.Result{Hits: make([]searchindex.Hit, 0, len(res.Hits)), Total: int64(res.Total)}
+	for _, match := range res.Hits {
+		id, err := b.ParseValue(b.PrimaryKey, match.ID)
+		if err != nil {
+			return searchindex.Result{}, err
+		}
+		hit := searchindex.Hit{ID: id, Score: match.Score}
+		// fields without matches are returned without marks, and are left out
+		for field, fragments := range match.Fragments {
+			for _, fragment := range fragments {
+				if !strings.Contains(fragment, "<mark>") {
+					continue
+				}
+				if hit.Highlights == nil {
+					hit.Highlights = make(map[string][]string, len(match.Fragments))
+				}
+				hit.Highlights[field] = append(hit.Highlights[field], fragment)
+			}
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
+}
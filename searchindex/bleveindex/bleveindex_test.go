@@ -0,0 +1,106 @@
+package bleveindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/franciscoescher/gosimplerest/resource"
+	"github.com/franciscoescher/gosimplerest/searchindex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userResource = resource.Resource{
+	Name:       "users",
+	PrimaryKey: "id",
+	Fields: map[string]resource.Field{
+		"id":         {Type: resource.TypeInt},
+		"first_name": {Type: resource.TypeString, FullText: true},
+		"last_name":  {Type: resource.TypeString, FullText: true},
+		"phone":      {Type: resource.TypeString},
+	},
+}
+
+func testIndex(t *testing.T, index *Index) {
+	ctx := context.Background()
+	for _, row := range []map[string]any{
+		{"id": int64(1), "first_name": "Ana Maria", "last_name": "Silva", "phone": "555"},
+		{"id": int64(2), "first_name": "Mariana", "last_name": "Souza", "phone": "555"},
+		{"id": int64(3), "first_name": "Fulano", "last_name": "Silva Silva", "phone": nil},
+		{"id": int64(4), "first_name": "Ciclano", "last_name": nil},
+	} {
+		require.NoError(t, index.Index(ctx, &userResource, row))
+	}
+
+	ids := func(text string) []any {
+		result, err := index.Search(ctx, &userResource, searchindex.Query{Text: text})
+		require.NoError(t, err)
+		ids := make([]any, len(result.Hits))
+		for i, hit := range result.Hits {
+			ids[i] = hit.ID
+		}
+		return ids
+	}
+	// prefixes, typos and every term
+	assert.Equal(t, []any{int64(1), int64(2)}, ids("mari"))
+	assert.ElementsMatch(t, []any{int64(1), int64(3)}, ids("silvq"))
+	assert.Equal(t, []any{int64(1)}, ids("ana silva"))
+	assert.Equal(t, []any{}, ids("555"))
+
+	result, err := index.Search(ctx, &userResource, searchindex.Query{Text: "silva", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, result.Total)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, int64(3), result.Hits[0].ID)
+	assert.Greater(t, result.Hits[0].Score, 0.0)
+	assert.Equal(t, map[string][]string{"last_name": {"<mark>Silva</mark> <mark>Silva</mark>"}}, result.Hits[0].Highlights)
+
+	// rows are replaced and deleted by their primary key
+	require.NoError(t, index.Index(ctx, &userResource, map[string]any{"id": int64(2), "first_name": "Beltrana"}))
+	assert.Equal(t, []any{int64(1)}, ids("mari"))
+	require.NoError(t, index.Delete(ctx, &userResource, int64(1)))
+	assert.Equal(t, []any{}, ids("mari"))
+
+	_, err = index.Search(ctx, &userResource, searchindex.Query{Text: " ! "})
+	assert.Error(t, err)
+	assert.Error(t, index.Index(ctx, &userResource, map[string]any{"first_name": "Ana"}))
+}
+
+func TestIndexInMemory(t *testing.T) {
+	index := New("")
+	defer index.Close()
+	testIndex(t, index)
+}
+
+func TestIndexInDirectory(t *testing.T) {
+	dir := t.TempDir()
+	index := New(dir)
+	testIndex(t, index)
+	require.NoError(t, index.Close())
+
+	// the index is kept after it is closed
+	index = New(dir)
+	defer index.Close()
+	result, err := index.Search(context.Background(), &userResource, searchindex.Query{Text: "fulano"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, result.Total)
+}
+
+func TestIndexMappingChanged(t *testing.T) {
+	dir := t.TempDir()
+	index := New(dir)
+	require.NoError(t, index.Index(context.Background(), &userResource, map[string]any{"id": int64(1), "first_name": "Ana"}))
+	require.NoError(t, index.Close())
+
+	// the index in the directory is not matched with other full-text fields
+	changed := userResource
+	changed.Fields = map[string]resource.Field{}
+	for name, field := range userResource.Fields {
+		changed.Fields[name] = field
+	}
+	changed.Fields["phone"] = resource.Field{Type: resource.TypeString, FullText: true}
+	index = New(dir)
+	defer index.Close()
+	_, err := index.Search(context.Background(), &changed, searchindex.Query{Text: "ana"})
+	assert.ErrorIs(t, err, ErrMappingChanged)
+}
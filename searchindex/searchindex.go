@@ -0,0 +1,69 @@
+// Package searchindex defines the secondary index the search route can use for the q param,
+// for relevance ranked, fuzzy and prefix search that the repositories can not do well.
+// The index only holds the full-text fields of the rows, and the routes read the rows
+// it returns from the repository, so it can always be rebuilt from it.
+package searchindex
+
+import (
+	"context"
+
+	"github.com/franciscoescher/gosimplerest/repository"
+	"github.com/franciscoescher/gosimplerest/resource"
+)
+
+// SearchIndex is a full-text index of the rows of resources, kept in sync
+// by the routes that create, update and delete rows
+type SearchIndex interface {
+	// Index adds the full-text fields of a row of the resource to the index,
+	// replacing the row with the same primary key
+	Index(ctx context.Context, b *resource.Resource, row map[string]any) error
+	// Delete removes the row with the primary key from the index, if it is in it
+	Delete(ctx context.Context, b *resource.Resource, id any) error
+	// Search returns the page of the rows matching the text of the query,
+	// ordered by descending score
+	Search(ctx context.Context, b *resource.Resource, q Query) (Result, error)
+}
+
+// Query is a search of the index
+type Query struct {
+	// Text is matched against the full-text fields of the resource
+	Text string
+	// Limit is the maximum number of hits returned, if 0, all hits are returned
+	Limit int
+	// Offset is the number of hits skipped
+	Offset int
+}
+
+// Result is a page of the hits of a search
+type Result struct {
+	Hits []Hit
+	// Total is the number of rows matching the search, ignoring the limit and offset
+	Total int64
+}
+
+// Hit is a row matching a search
+type Hit struct {
+	// ID is the primary key of the row, parsed to the type of the field
+	ID any
+	// Score is the relevance of the row to the search, higher is better
+	Score float64
+	// Highlights are fragments of the values of the fields that matched the search,
+	// with the matched words in <mark> tags
+	Highlights map[string][]string
+}
+
+// Rebuild indexes all the rows of the resource that are not soft deleted,
+// such as the rows created before the index was used
+func Rebuild(ctx context.Context, index SearchIndex, repo repository.RepositoryInterface, b *resource.Resource) error {
+	rows, err := repo.Search(ctx, b, repository.Query{})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err = index.Index(ctx, b, row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}